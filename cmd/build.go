@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	buildBlog string
+	buildOut  string
+)
+
+var buildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Render the blog content into a static folder",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runBuild(); err != nil {
+			logrus.WithError(err).Fatal("failed to build")
+		}
+	},
+}
+
+func runBuild() error {
+	router, err := openRouter(buildBlog, false)
+	if err != nil {
+		return err
+	}
+	logrus.WithField("out", buildOut).Info("rendering blog")
+	if err := router.Save(buildOut); err != nil {
+		return fmt.Errorf("save: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	buildCmd.Flags().StringVarP(&buildBlog, "blog", "b", "content", "Blog folder to build")
+	buildCmd.Flags().StringVarP(&buildOut, "out", "o", "public", "Output folder for the rendered blog")
+	rootCmd.AddCommand(buildCmd)
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/lnsp/bloggy/pkg/check"
+	"github.com/lnsp/bloggy/pkg/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var checkBlog string
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check the blog content for problems",
+	Run: func(cmd *cobra.Command, args []string) {
+		problems, err := runCheck()
+		if err != nil {
+			logrus.WithError(err).Fatal("failed to check")
+		}
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+		if len(problems) > 0 {
+			logrus.WithField("problems", len(problems)).Fatal("check failed")
+		}
+		logrus.Info("no problems found")
+	},
+}
+
+func runCheck() ([]check.Problem, error) {
+	cfg, err := config.Load(checkBlog)
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	problems := check.Files(cfg)
+	router, err := openRouter(checkBlog, false)
+	if err != nil {
+		return append(problems, check.Problem{Source: checkBlog, Message: err.Error()}), nil
+	}
+	return append(problems, check.Routes(cfg, router)...), nil
+}
+
+func init() {
+	checkCmd.Flags().StringVarP(&checkBlog, "blog", "b", "content", "Blog folder to check")
+	rootCmd.AddCommand(checkCmd)
+}
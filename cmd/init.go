@@ -1,15 +1,23 @@
 package cmd
 
 import (
+	"errors"
+	"io"
+	"io/ioutil"
 	"os"
-	"os/exec"
+	"path/filepath"
 
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+	"github.com/lnsp/bloggy/pkg/blueprint"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 var initRepository string
 var initBlog string
+var initForce bool
 
 var initCmd = &cobra.Command{
 	Use:   "init",
@@ -22,32 +30,109 @@ var initCmd = &cobra.Command{
 }
 
 func init() {
+	client.InstallProtocol("file", server.DefaultServer)
 	initCmd.Flags().StringVarP(&initBlog, "blog", "b", "content", "Blog folder to use")
-	initCmd.Flags().StringVarP(&initRepository, "repository", "r", "https://github.com/lnsp/bloggy-blueprint", "Repository to clone from")
+	initCmd.Flags().StringVarP(&initRepository, "repository", "r", "", "Repository to clone from instead of the built-in blueprint")
+	initCmd.Flags().BoolVarP(&initForce, "force", "f", false, "Initialize the blog folder even if it is not empty")
 	rootCmd.AddCommand(initCmd)
 }
 
 func runInit() error {
-	// delete existing folder
-	remError := os.RemoveAll(initBlog)
-	if remError != nil && !os.IsNotExist(remError) {
-		return remError
+	// refuse to touch existing blogs
+	empty, err := isEmptyDir(initBlog)
+	if err != nil {
+		return err
+	}
+	if !empty && !initForce {
+		return errors.New("blog folder is not empty, use --force to initialize it anyway")
 	}
 
-	cloneCmd := exec.Command("git", "clone", initRepository, initBlog)
-	// run git
-	cloneStartError := cloneCmd.Start()
-	// failed to run git
-	if cloneStartError != nil {
-		return cloneStartError
+	if initRepository == "" {
+		logrus.WithField("blog", initBlog).Info("writing blueprint")
+		return blueprint.Write(initBlog)
 	}
+
+	// clone without requiring a git binary
 	logrus.WithField("url", initRepository).Info("cloning repository")
-	// wait for git to finish
-	cloneWaitError := cloneCmd.Wait()
-	// git runtime error
-	if cloneWaitError != nil {
-		return cloneWaitError
+	if empty {
+		return cloneRepository(initRepository, initBlog)
+	}
+
+	// checking out into a non-empty folder removes untracked files,
+	// so clone into a temporary folder and only overwrite files of the same name
+	tmp, err := ioutil.TempDir("", "bloggy-init")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	if err := cloneRepository(initRepository, tmp); err != nil {
+		return err
+	}
+	logrus.WithField("blog", initBlog).Info("copying repository without its git folder")
+	return copyTree(tmp, initBlog)
+}
+
+// cloneRepository clones a local or remote repository into a folder.
+func cloneRepository(repository, folder string) error {
+	_, err := git.PlainClone(folder, false, &git.CloneOptions{
+		URL: localRepository(repository),
+	})
+	return err
+}
+
+// copyTree copies the files of a folder except its git folder, overwriting existing files of the same name.
+func copyTree(src, dst string) error {
+	return filepath.Walk(src, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, file)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && rel == git.GitDirName {
+			return filepath.SkipDir
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, data, info.Mode().Perm())
+	})
+}
+
+// localRepository resolves a local repository to the absolute path of its git folder,
+// which is served in-process instead of running git-upload-pack.
+func localRepository(repository string) string {
+	if _, err := os.Stat(repository); err != nil {
+		return repository
 	}
+	abs, err := filepath.Abs(repository)
+	if err != nil {
+		return repository
+	}
+	if info, err := os.Stat(filepath.Join(abs, git.GitDirName)); err == nil && info.IsDir() {
+		return filepath.Join(abs, git.GitDirName)
+	}
+	return abs
+}
 
-	return nil
+// isEmptyDir reports whether a folder is empty or does not exist.
+func isEmptyDir(folder string) (bool, error) {
+	dir, err := os.Open(folder)
+	if os.IsNotExist(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	defer dir.Close()
+	_, err = dir.Readdirnames(1)
+	if err == io.EOF {
+		return true, nil
+	}
+	return false, err
 }
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lnsp/bloggy/pkg/config"
+	"github.com/lnsp/bloggy/pkg/content"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var newBlog string
+
+var newCmd = &cobra.Command{
+	Use:   "new",
+	Short: "Create a new post or page",
+}
+
+// newArchetypeCmd creates the subcommand scaffolding files from the given archetype.
+func newArchetypeCmd(archetype string) *cobra.Command {
+	return &cobra.Command{
+		Use:   archetype + " [title]",
+		Short: "Create a new " + archetype,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runNew(archetype, args[0]); err != nil {
+				logrus.WithError(err).Fatal("failed to create " + archetype)
+			}
+		},
+	}
+}
+
+func runNew(archetype, title string) error {
+	cfg, err := config.Load(newBlog)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	file, err := content.Scaffold(cfg, archetype, title, time.Now())
+	if err != nil {
+		return err
+	}
+	logrus.WithField("file", file).Info("created " + archetype)
+	return nil
+}
+
+func init() {
+	newCmd.PersistentFlags().StringVarP(&newBlog, "blog", "b", "content", "Blog folder to create the file in")
+	newCmd.AddCommand(newArchetypeCmd(content.ArchetypePost), newArchetypeCmd(content.ArchetypePage))
+	rootCmd.AddCommand(newCmd)
+}
@@ -1,17 +1,25 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"github.com/lnsp/bloggy/pkg/config"
 	"github.com/lnsp/bloggy/pkg/content"
 	"github.com/lnsp/bloggy/pkg/routes"
+	"github.com/lnsp/bloggy/pkg/watch"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 var (
-	serveBlog string
+	serveBlog   string
+	serveWatch  bool
+	serveDrafts bool
 )
 
 var serveCmd = &cobra.Command{
@@ -25,30 +33,94 @@ var serveCmd = &cobra.Command{
 }
 
 func runServe() error {
+	router, err := openRouter(serveBlog, serveDrafts)
+	if err != nil {
+		return err
+	}
+	var mu sync.Mutex
+	reload := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		reloadRouter(router, serveBlog, serveDrafts)
+	}
+	// Watch for changes
+	if serveWatch {
+		watcher, err := watch.New(serveBlog, watch.DefaultDelay, reload)
+		if err != nil {
+			return fmt.Errorf("watch: %w", err)
+		}
+		defer watcher.Close()
+	}
+	// Reload on SIGHUP, shut down on SIGINT and SIGTERM
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		for sig := range signals {
+			logrus.WithField("signal", sig).Info("received signal")
+			if sig == syscall.SIGHUP {
+				reload()
+				continue
+			}
+			cancel()
+			return
+		}
+	}()
+	// Wait and listen
+	return router.Serve(ctx)
+}
+
+// openRouter loads the config, content and templates of a blog folder and creates a router.
+func openRouter(folder string, drafts bool) (*routes.Router, error) {
+	cfg, templater, err := openTemplater(folder, drafts)
+	if err != nil {
+		return nil, err
+	}
+	// Open server
+	return routes.NewRouter(cfg, templater), nil
+}
+
+// reloadRouter loads the blog folder again and swaps the new content into the router.
+// The router keeps its current state if loading fails.
+func reloadRouter(router *routes.Router, folder string, drafts bool) {
+	cfg, templater, err := openTemplater(folder, drafts)
+	if err != nil {
+		logrus.WithError(err).Error("failed to reload")
+		return
+	}
+	router.Reload(cfg, templater)
+	logrus.WithField("blog", folder).Info("reloaded blog")
+}
+
+// openTemplater loads the config, content and templates of a blog folder.
+// If drafts is set, drafts and scheduled posts are published as well.
+func openTemplater(folder string, drafts bool) (*config.Config, *content.Templater, error) {
 	// Open config
-	cfg, err := config.Load(serveBlog)
+	cfg, err := config.Load(folder)
 	if err != nil {
-		return fmt.Errorf("load config: %w", err)
+		return nil, nil, fmt.Errorf("load config: %w", err)
 	}
+	cfg.Drafts = cfg.Drafts || drafts
 	// Create resolver
 	resolver := routes.NewResolver(cfg)
 	// Open indexer
 	index, err := content.NewIndex(cfg, resolver)
 	if err != nil {
-		return fmt.Errorf("new index: %w", err)
+		return nil, nil, fmt.Errorf("new index: %w", err)
 	}
 	// Open templater
 	templater, err := content.NewTemplater(cfg, index)
 	if err != nil {
-		return fmt.Errorf("new templater: %w", err)
+		return nil, nil, fmt.Errorf("new templater: %w", err)
 	}
-	// Open server
-	router := routes.NewRouter(cfg, templater)
-	// Wait and listen
-	return router.Serve()
+	return cfg, templater, nil
 }
 
 func init() {
 	serveCmd.Flags().StringVarP(&serveBlog, "blog", "b", "content", "Blog folder to serve")
+	serveCmd.Flags().BoolVarP(&serveWatch, "watch", "w", true, "Reload the blog when files change")
+	serveCmd.Flags().BoolVar(&serveDrafts, "drafts", false, "Publish drafts and scheduled posts")
 	rootCmd.AddCommand(serveCmd)
 }
@@ -0,0 +1,38 @@
+// Package blueprint bundles the files of a new blog into the binary.
+package blueprint
+
+import (
+	"embed"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// root is the folder of the blueprint inside the embedded files.
+const root = "files"
+
+//go:embed files
+var files embed.FS
+
+// Write copies the blueprint into the folder, overwriting existing files of the same name.
+func Write(folder string) error {
+	return fs.WalkDir(files, root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, filepath.FromSlash(name))
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(folder, rel)
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		data, err := files.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, data, 0644)
+	})
+}
@@ -0,0 +1,170 @@
+// Package check finds problems in the content, templates and links of a blog.
+package check
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/lnsp/bloggy/pkg/config"
+	"github.com/lnsp/bloggy/pkg/content"
+	"github.com/lnsp/bloggy/pkg/routes"
+	"golang.org/x/net/html"
+)
+
+// Problem describes an issue found in a file or route of the blog.
+type Problem struct {
+	Source  string
+	Message string
+}
+
+func (p Problem) String() string {
+	return p.Source + ": " + p.Message
+}
+
+// linkAttributes maps each element to the attribute linking other resources.
+var linkAttributes = map[string]string{
+	"a":      "href",
+	"link":   "href",
+	"img":    "src",
+	"script": "src",
+	"source": "src",
+	"video":  "src",
+	"audio":  "src",
+	"iframe": "src",
+}
+
+// Files checks the blog URL, the front matter of all posts and pages and the configured favicon.
+func Files(cfg *config.Config) []Problem {
+	var problems []Problem
+	if err := cfg.ValidateURL(); err != nil {
+		problems = append(problems, Problem{config.DefaultConfigFile, err.Error()})
+	}
+	problems = append(problems, entries(cfg, content.PostsFolder, true)...)
+	problems = append(problems, entries(cfg, content.PagesFolder, false)...)
+	if cfg.Meta.Favicon != "" {
+		if _, err := os.Stat(cfg.Path(cfg.Meta.Favicon)); err != nil {
+			problems = append(problems, Problem{config.DefaultConfigFile, "missing favicon " + cfg.Meta.Favicon})
+		}
+	}
+	return problems
+}
+
+// entries checks the markdown files of a content folder.
+func entries(cfg *config.Config, folder string, posts bool) []Problem {
+	files, err := filepath.Glob(filepath.Join(cfg.Base, folder, "*.md"))
+	if err != nil {
+		return []Problem{{folder, err.Error()}}
+	}
+	var problems []Problem
+	slugs := make(map[string]string)
+	for _, file := range files {
+		source, _ := filepath.Rel(cfg.Base, file)
+		data, err := content.ParseFile(file)
+		if err != nil {
+			problems = append(problems, Problem{source, "invalid front matter: " + err.Error()})
+			continue
+		}
+		if strings.TrimSpace(data.Title) == "" {
+			problems = append(problems, Problem{source, "missing title"})
+		}
+		if posts && data.PublishDate != "" {
+			if _, err := content.ParseDate(data.PublishDate, cfg.Location); err != nil {
+				problems = append(problems, Problem{source, "bad date: " + err.Error()})
+			}
+		}
+		if other, ok := slugs[data.Slug]; ok {
+			problems = append(problems, Problem{source, fmt.Sprintf("duplicate slug %q, also used by %s", data.Slug, other)})
+		} else {
+			slugs[data.Slug] = source
+		}
+	}
+	return problems
+}
+
+// Routes renders every route of the router and checks that all internal links and static assets exist.
+func Routes(cfg *config.Config, router *routes.Router) []Problem {
+	var problems []Problem
+	host := ""
+	if blog, err := url.Parse(cfg.Meta.URL); err == nil {
+		host = blog.Host
+	}
+	statuses := make(map[string]int)
+	status := func(target string) int {
+		if code, ok := statuses[target]; ok {
+			return code
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		statuses[target] = recorder.Code
+		return recorder.Code
+	}
+	reported := make(map[string]bool)
+	for _, page := range router.URLs() {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, page, nil))
+		statuses[page] = recorder.Code
+		if recorder.Code != http.StatusOK {
+			problems = append(problems, Problem{page, fmt.Sprintf("failed to render, status %d", recorder.Code)})
+			continue
+		}
+		if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/html") {
+			continue
+		}
+		base := &url.URL{Path: page}
+		for _, link := range links(recorder.Body.String()) {
+			ref, err := url.Parse(link)
+			if err != nil {
+				problems = append(problems, Problem{page, fmt.Sprintf("invalid link %q", link)})
+				continue
+			}
+			ref = base.ResolveReference(ref)
+			if ref.Scheme != "" && ref.Scheme != "http" && ref.Scheme != "https" || ref.Host != "" && ref.Host != host {
+				continue
+			}
+			target := path.Clean("/" + ref.Path)
+			if strings.HasSuffix(ref.Path, "/") && target != "/" {
+				target += "/"
+			}
+			if reported[target] || status(target) < http.StatusBadRequest {
+				continue
+			}
+			reported[target] = true
+			if strings.HasPrefix(target, routes.StaticBaseURL) {
+				problems = append(problems, Problem{page, "missing static asset " + target})
+			} else {
+				problems = append(problems, Problem{page, "broken link " + target})
+			}
+		}
+	}
+	return problems
+}
+
+// links returns the targets of all links and embedded resources in a HTML document.
+func links(document string) []string {
+	root, err := html.Parse(strings.NewReader(document))
+	if err != nil {
+		return nil
+	}
+	var targets []string
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if attribute, ok := linkAttributes[node.Data]; ok && node.Type == html.ElementNode {
+			for _, attr := range node.Attr {
+				if attr.Key == attribute && attr.Val != "" {
+					targets = append(targets, attr.Val)
+				}
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+	return targets
+}
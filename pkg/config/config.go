@@ -1,23 +1,49 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"net/url"
 	"path"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/go-yaml/yaml"
+	"github.com/sirupsen/logrus"
 )
 
-// DefaultConfigFile is the default name of the configuration file.
-const DefaultConfigFile = "config.yaml"
+const (
+	// DefaultConfigFile is the default name of the configuration file.
+	DefaultConfigFile = "config.yaml"
+	// DefaultPageSize is the default number of posts per index page.
+	DefaultPageSize = 10
+	// DefaultWordsPerMinute is the default reading speed used to estimate the reading time of posts.
+	DefaultWordsPerMinute = 200
+	// DefaultShutdownTimeout is the default time to wait for open connections when shutting down.
+	DefaultShutdownTimeout = 10 * time.Second
+	// DefaultPermalink is the default URL pattern of posts.
+	DefaultPermalink = "/post/:slug"
+)
 
 // Config represents the blog configuration.
 type Config struct {
-	Base   string
-	Server struct {
-		Port    int
-		TLSPort int
+	Base     string
+	Drafts   bool
+	Timezone string
+	// Location is the time zone loaded from the configured name.
+	Location *time.Location `yaml:"-"`
+	Server   struct {
+		Port            int
+		TLSPort         int
+		Certificate     string
+		Key             string
+		Redirect        bool
+		ShutdownTimeout time.Duration
 	}
 	Meta struct {
+		URL      string
 		Country  string
 		Title    string
 		Subtitle string
@@ -27,7 +53,20 @@ type Config struct {
 		Name  string
 		Email string
 	}
-	Links map[string]string
+	Posts struct {
+		PageSize       int
+		WordsPerMinute int
+		Permalink      string
+	}
+	Robots struct {
+		Disallow []string
+	}
+	Markdown struct {
+		Renderer  string
+		Highlight string
+	}
+	Links     map[string]string
+	Redirects map[string]string
 }
 
 // Load loads the blog configuration.
@@ -42,5 +81,71 @@ func Load(folder string) (*Config, error) {
 		return nil, err
 	}
 	cfg.Base = folder
+	if cfg.Posts.PageSize <= 0 {
+		cfg.Posts.PageSize = DefaultPageSize
+	}
+	if cfg.Posts.WordsPerMinute <= 0 {
+		cfg.Posts.WordsPerMinute = DefaultWordsPerMinute
+	}
+	if cfg.Server.ShutdownTimeout <= 0 {
+		cfg.Server.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	cfg.Location, err = time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Posts.Permalink == "" {
+		cfg.Posts.Permalink = DefaultPermalink
+	}
+	if !strings.HasPrefix(cfg.Posts.Permalink, "/") || !strings.Contains(cfg.Posts.Permalink, ":slug") {
+		return nil, errors.New("permalink must start with a slash and contain :slug")
+	}
+	// Pages are served at /{slug}, which a single segment permalink would shadow
+	if !strings.Contains(strings.Trim(cfg.Posts.Permalink, "/"), "/") && !strings.HasSuffix(cfg.Posts.Permalink, "/") {
+		return nil, fmt.Errorf("permalink %q collides with page URLs, add a folder or a trailing slash", cfg.Posts.Permalink)
+	}
+	// Redirected paths are matched against absolute request paths
+	redirects := make(map[string]string, len(cfg.Redirects))
+	for from, to := range cfg.Redirects {
+		if !strings.HasPrefix(from, "/") {
+			from = "/" + from
+		}
+		redirects[from] = to
+	}
+	cfg.Redirects = redirects
+	if err := cfg.ValidateURL(); err != nil {
+		logrus.WithError(err).Warn("feeds, sitemap and robots.txt will contain relative URLs")
+	}
 	return &cfg, nil
 }
+
+// ValidateURL reports an error if the blog URL is not an absolute URL.
+// The sitemap and feeds require absolute URLs to be valid.
+func (cfg *Config) ValidateURL() error {
+	if cfg.Meta.URL == "" {
+		return errors.New("meta.url is not set")
+	}
+	blog, err := url.Parse(cfg.Meta.URL)
+	if err != nil {
+		return fmt.Errorf("meta.url: %w", err)
+	}
+	if !blog.IsAbs() || blog.Host == "" {
+		return fmt.Errorf("meta.url %q is not an absolute URL", cfg.Meta.URL)
+	}
+	return nil
+}
+
+// AbsoluteURL prefixes a path with the configured blog URL.
+// If no blog URL is configured, the path is returned as-is.
+func (cfg *Config) AbsoluteURL(p string) string {
+	return strings.TrimSuffix(cfg.Meta.URL, "/") + p
+}
+
+// Path resolves a path relative to the blog folder.
+// Absolute paths are returned as-is.
+func (cfg *Config) Path(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(cfg.Base, p)
+}
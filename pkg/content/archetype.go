@@ -0,0 +1,132 @@
+package content
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-yaml/yaml"
+	"github.com/lnsp/bloggy/pkg/config"
+)
+
+const (
+	// ArchetypeFolder is the folder containing the templates of new posts and pages.
+	ArchetypeFolder = "archetypes"
+	// ArchetypePost is the name of the post archetype.
+	ArchetypePost = "post"
+	// ArchetypePage is the name of the page archetype.
+	ArchetypePage = "page"
+)
+
+// defaultArchetypes are used if the blog does not have its own archetypes.
+var defaultArchetypes = map[string]string{
+	ArchetypePost: `---
+title: {{ yaml .Title }}
+date: {{ .Date }}
+slug: {{ .Slug }}
+tags: []
+---
+
+`,
+	ArchetypePage: `---
+title: {{ yaml .Title }}
+slug: {{ .Slug }}
+---
+
+`,
+}
+
+// archetypeFolders maps each archetype to the folder of the created files.
+var archetypeFolders = map[string]string{
+	ArchetypePost: PostsFolder,
+	ArchetypePage: PagesFolder,
+}
+
+// ArchetypeData is passed to the archetype templates.
+type ArchetypeData struct {
+	Title string
+	Slug  string
+	Date  string
+}
+
+// Scaffold creates a new post or page with the given title from its archetype and returns the path of the file.
+// Archetypes are loaded from archetypes/post.md and archetypes/page.md in the blog folder, falling back to a default header.
+func Scaffold(cfg *config.Config, archetype, title string, now time.Time) (string, error) {
+	folder, ok := archetypeFolders[archetype]
+	if !ok {
+		return "", fmt.Errorf("unknown archetype %q", archetype)
+	}
+	slug := Slugify(title)
+	if slug == "" {
+		return "", errors.New("title does not contain any letters or digits")
+	}
+	if err := checkSlug(cfg.Path(folder), slug); err != nil {
+		return "", err
+	}
+	source, ok := defaultArchetypes[archetype]
+	custom, err := ioutil.ReadFile(cfg.Path(filepath.Join(ArchetypeFolder, archetype+".md")))
+	if err == nil {
+		source = string(custom)
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("read archetype: %w", err)
+	}
+	tmpl, err := template.New(archetype).Funcs(template.FuncMap{"yaml": yamlValue}).Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("parse archetype: %w", err)
+	}
+	var buffer bytes.Buffer
+	err = tmpl.Execute(&buffer, ArchetypeData{
+		Title: title,
+		Slug:  slug,
+		Date:  now.In(cfg.Location).Format(FileDateFormat),
+	})
+	if err != nil {
+		return "", fmt.Errorf("execute archetype: %w", err)
+	}
+	if err := os.MkdirAll(cfg.Path(folder), 0755); err != nil {
+		return "", err
+	}
+	file := cfg.Path(filepath.Join(folder, slug+".md"))
+	output, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer output.Close()
+	if _, err := buffer.WriteTo(output); err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+// checkSlug reports an error if a file in the folder already uses the slug.
+func checkSlug(folder, slug string) error {
+	files, err := filepath.Glob(filepath.Join(folder, "*.md"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := ParseFile(file)
+		if err != nil {
+			continue
+		}
+		if data.Slug == slug {
+			return fmt.Errorf("slug %q is already used by %s", slug, file)
+		}
+	}
+	return nil
+}
+
+// yamlValue encodes a value for use in a YAML header.
+func yamlValue(value interface{}) (string, error) {
+	encoded, err := yaml.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(encoded)), nil
+}
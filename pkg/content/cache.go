@@ -0,0 +1,50 @@
+package content
+
+import "sync"
+
+// contextCache stores created contexts and is safe for concurrent use.
+type contextCache struct {
+	mu         sync.Mutex
+	entries    map[string]interface{}
+	generation uint64
+}
+
+// newContextCache creates an empty context cache.
+func newContextCache() *contextCache {
+	return &contextCache{entries: make(map[string]interface{})}
+}
+
+// load returns the cached value of the key or creates and stores a new one.
+// Values created while the cache is cleared are returned, but not stored.
+func (c *contextCache) load(key string, create func() (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	value, ok := c.entries[key]
+	generation := c.generation
+	c.mu.Unlock()
+	if ok {
+		return value, nil
+	}
+	value, err := create()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		return value, nil
+	}
+	// Prefer a value stored by a concurrent call
+	if existing, ok := c.entries[key]; ok {
+		return existing, nil
+	}
+	c.entries[key] = value
+	return value, nil
+}
+
+// clear removes all values from the cache.
+func (c *contextCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]interface{})
+	c.generation++
+}
@@ -0,0 +1,101 @@
+package content
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestContextCacheLoad(t *testing.T) {
+	cache := newContextCache()
+	var calls int
+	create := func() (interface{}, error) {
+		calls++
+		return calls, nil
+	}
+	for i := 0; i < 3; i++ {
+		value, err := cache.load("key", create)
+		if err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		if value != 1 {
+			t.Errorf("load #%d = %v, want 1", i, value)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("create called %d times, want 1", calls)
+	}
+}
+
+func TestContextCacheLoadError(t *testing.T) {
+	cache := newContextCache()
+	failure := errors.New("failure")
+	if _, err := cache.load("key", func() (interface{}, error) { return nil, failure }); err != failure {
+		t.Fatalf("load error = %v, want %v", err, failure)
+	}
+	value, err := cache.load("key", func() (interface{}, error) { return "value", nil })
+	if err != nil || value != "value" {
+		t.Errorf("load after error = %v, %v, want value", value, err)
+	}
+}
+
+func TestContextCacheClear(t *testing.T) {
+	cache := newContextCache()
+	cache.load("key", func() (interface{}, error) { return "old", nil })
+	cache.clear()
+	value, _ := cache.load("key", func() (interface{}, error) { return "new", nil })
+	if value != "new" {
+		t.Errorf("load after clear = %v, want new", value)
+	}
+}
+
+func TestContextCacheClearWhileCreating(t *testing.T) {
+	cache := newContextCache()
+	value, _ := cache.load("key", func() (interface{}, error) {
+		cache.clear()
+		return "stale", nil
+	})
+	if value != "stale" {
+		t.Errorf("load = %v, want stale", value)
+	}
+	value, _ = cache.load("key", func() (interface{}, error) { return "fresh", nil })
+	if value != "fresh" {
+		t.Errorf("load after clear = %v, want fresh", value)
+	}
+}
+
+func TestContextCacheConcurrent(t *testing.T) {
+	cache := newContextCache()
+	var wg sync.WaitGroup
+	var creates int64
+	for worker := 0; worker < 16; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				key := fmt.Sprint("key", (worker+i)%8)
+				value, err := cache.load(key, func() (interface{}, error) {
+					atomic.AddInt64(&creates, 1)
+					return key, nil
+				})
+				if err != nil {
+					t.Errorf("load %s: %v", key, err)
+					return
+				}
+				if value != key {
+					t.Errorf("load %s = %v", key, value)
+					return
+				}
+				if i%50 == 0 {
+					cache.clear()
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+	if creates == 0 {
+		t.Error("create was never called")
+	}
+}
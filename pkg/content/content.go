@@ -3,18 +3,16 @@ package content
 import (
 	"bufio"
 	"fmt"
+	"html/template"
 	"os"
 	"path"
 	"path/filepath"
-	"regexp"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/go-yaml/yaml"
 	"github.com/lnsp/bloggy/pkg/config"
-	"github.com/microcosm-cc/bluemonday"
-	"github.com/russross/blackfriday"
 	"github.com/sirupsen/logrus"
 )
 
@@ -23,22 +21,33 @@ const (
 	PostsFolder = "posts"
 	// PagesFolder is the default folder for blog pages.
 	PagesFolder = "pages"
-	// FileDateFormat is the date format required in a post's header.
+	// FileDateFormat is the default date format in a post's header.
 	FileDateFormat = "2006-Jan-02"
+	// MoreSeparator separates the excerpt of a post from the rest of its content.
+	MoreSeparator = "<!--more-->"
 )
 
 type URLResolver interface {
 	Page(string) string
-	Post(string) string
+	Post(string, time.Time) string
+	Tag(string) string
+	Index(int) string
 }
 
 // ParseData stores the parsed data of a file.
 type ParseData struct {
-	Title       string `yaml:"title"`
-	Subtitle    string `yaml:"subtitle"`
-	PublishDate string `yaml:"date"`
-	Slug        string `yaml:"slug"`
+	Title       string   `yaml:"title"`
+	Subtitle    string   `yaml:"subtitle"`
+	PublishDate string   `yaml:"date"`
+	Slug        string   `yaml:"slug"`
+	Tags        []string `yaml:"tags"`
+	Draft       bool     `yaml:"draft"`
+	TOC         bool     `yaml:"toc"`
+	Summary     string   `yaml:"summary"`
+	Aliases     []string `yaml:"aliases"`
 	content     string
+	file        string
+	modTime     time.Time
 }
 
 // SetContent sets the parsed content.
@@ -51,6 +60,16 @@ func (p *ParseData) Content() string {
 	return p.content
 }
 
+// File returns the path of the parsed file.
+func (p *ParseData) File() string {
+	return p.file
+}
+
+// ModTime returns the modification time of the parsed file.
+func (p *ParseData) ModTime() time.Time {
+	return p.modTime
+}
+
 // Entry has content and be located by an URL.
 type Entry interface {
 	GetContent() string
@@ -64,7 +83,15 @@ type Post struct {
 	Subtitle    string
 	PublishDate time.Time
 	Slug        string
+	Tags        []string
+	Draft       bool
+	TOC         bool
 	Content     string
+	Excerpt     template.HTML
+	WordCount   int
+	ReadingTime int
+	ModTime     time.Time
+	Aliases     []string
 	Resolver    URLResolver
 }
 
@@ -80,9 +107,20 @@ func (p *Post) GetTitle() string {
 
 // GetURL generates a URL from the post route url and the post slug.
 func (p *Post) GetURL() string {
-	rgx, _ := regexp.Compile("[^A-Za-z\\-]")
-	slugged := rgx.ReplaceAllString(p.Slug, "")
-	return p.Resolver.Post(strings.ToLower(slugged))
+	return p.Resolver.Post(p.Slug, p.PublishDate)
+}
+
+// Published reports whether the post is neither a draft nor scheduled after the given time.
+func (p *Post) Published(now time.Time) bool {
+	return !p.Draft && !p.PublishDate.After(now)
+}
+
+// LastModified returns the time the post was last changed, but not before its publishing date.
+func (p *Post) LastModified() time.Time {
+	if p.ModTime.After(p.PublishDate) {
+		return p.ModTime
+	}
+	return p.PublishDate
 }
 
 // Age returns the age of the post in seconds.
@@ -94,7 +132,10 @@ func (p *Post) Age() int64 {
 type Page struct {
 	Title    string
 	Slug     string
+	TOC      bool
 	Content  string
+	ModTime  time.Time
+	Aliases  []string
 	Resolver URLResolver
 }
 
@@ -110,9 +151,7 @@ func (p *Page) GetTitle() string {
 
 // GetURL generates a URL from the page route url and the page slug.
 func (p *Page) GetURL() string {
-	regex, _ := regexp.Compile("[^A-Za-z\\-]")
-	slugged := regex.ReplaceAllString(p.Slug, "")
-	return p.Resolver.Page(strings.ToLower(slugged))
+	return p.Resolver.Page(p.Slug)
 }
 
 // ByAge implements a interface to sort a slice of posts by publishing date.
@@ -129,7 +168,7 @@ func (b ByAge) Less(i, j int) bool {
 }
 
 type Index struct {
-	// Posts stores all blog posts.
+	// Posts stores all published blog posts.
 	Posts []Post
 
 	// Pages stores all blog pages.
@@ -141,23 +180,48 @@ type Index struct {
 	// PageBySlug matches each slug to its page.
 	PageBySlug map[string]*Page
 
+	// PostsByTag matches each tag to its posts, sorted by age.
+	PostsByTag map[string][]Post
+
+	// URLByAlias matches each alias path of the published posts and all pages to their current URL.
+	URLByAlias map[string]string
+
+	// Search stores the full-text index of the published posts and all pages.
+	Search *SearchIndex
+
+	// Drafts includes drafts and scheduled posts in the published posts.
+	Drafts bool
+
 	Resolver URLResolver
-}
 
-// Render generates HTML from an entries markdown content.
-func Render(e Entry) string {
-	output := blackfriday.MarkdownCommon([]byte(e.GetContent()))
-	return string(bluemonday.UGCPolicy().SanitizeBytes([]byte(output)))
+	// Renderer generates the HTML of posts and pages.
+	Renderer Renderer
+
+	// WordsPerMinute is the reading speed used to estimate the reading time of posts.
+	WordsPerMinute int
+
+	// Location is the time zone of post dates without an explicit zone.
+	Location *time.Location
+
+	// all stores every post including drafts and scheduled posts, sorted by age.
+	all []Post
+
+	// undated matches the files of posts without a date to their position in all while loading.
+	undated map[string]int
 }
 
-// parseFile parses a file and returns a pointer to the parsed data or an error.
-func parseFile(file string) (data *ParseData, err error) {
+// ParseFile parses a file and returns a pointer to the parsed data or an error.
+func ParseFile(file string) (data *ParseData, err error) {
 	// Open the post file
 	input, openError := os.Open(file)
 	if openError != nil {
 		return nil, openError
 	}
 	defer input.Close()
+	info, statError := input.Stat()
+	if statError != nil {
+		return nil, statError
+	}
 
 	// Scan all input lines
 	inputScanner := bufio.NewScanner(input)
@@ -184,6 +248,8 @@ func parseFile(file string) (data *ParseData, err error) {
 
 	data = new(ParseData)
 	data.SetContent(body)
+	data.file = file
+	data.modTime = info.ModTime()
 
 	// Decode JSON header
 	if err := yaml.Unmarshal([]byte(header), &data); err != nil {
@@ -191,10 +257,10 @@ func parseFile(file string) (data *ParseData, err error) {
 	}
 
 	// Generate slug from file name if needed
+	data.Slug = Slugify(data.Slug)
 	if len(data.Slug) == 0 {
-		data.Slug = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		data.Slug = Slugify(strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)))
 	}
-	data.Slug = strings.ToLower(data.Slug)
 	return data, nil
 }
 
@@ -212,15 +278,15 @@ func loadDirectory(dir string, callback func(*ParseData) error) error {
 	}).Debug("scanning directory")
 	for _, entry := range dirEntries {
 		// Parse file entry
-		data, err := parseFile(entry)
+		data, err := ParseFile(entry)
 		if err != nil {
-			logrus.WithField("file", entry).Warn("failed to parse")
+			logrus.WithError(err).WithField("file", entry).Warn("failed to parse")
 			continue
 		}
 
 		err = callback(data)
 		if err != nil {
-			logrus.WithField("file", entry).Warn("failed to callback")
+			logrus.WithError(err).WithField("file", entry).Warn("failed to callback")
 			continue
 		}
 	}
@@ -234,50 +300,191 @@ func (c *Index) AddPost(data *ParseData) error {
 		Title:    data.Title,
 		Subtitle: data.Subtitle,
 		Slug:     data.Slug,
+		Draft:    data.Draft,
+		TOC:      data.TOC,
 		Content:  data.Content(),
+		ModTime:  data.ModTime(),
+		Aliases:  data.Aliases,
 		Resolver: c.Resolver,
 	}
-	date, err := time.Parse(FileDateFormat, data.PublishDate)
+	seen := make(map[string]bool)
+	for _, tag := range data.Tags {
+		// Different tags may share a name, e.g. C and C#
+		if tag = TagName(tag); tag != "" && !seen[tag] {
+			seen[tag] = true
+			p.Tags = append(p.Tags, tag)
+		}
+	}
+	date, err := c.publishDate(data)
 	if err != nil {
 		return err
 	}
 	p.PublishDate = date
+	p.Excerpt = template.HTML(c.Renderer.Render(excerpt(data)))
+	p.WordCount = CountWords(c.Renderer.Render(p.Content))
+	p.ReadingTime = ReadingTime(p.WordCount, c.WordsPerMinute)
 
-	c.Posts = append(c.Posts, p)
-	c.PostBySlug[p.Slug] = &p
+	c.all = append(c.all, p)
 	return nil
 }
 
+// publishDate parses the date of a post.
+// Posts without a date are dated by their modification time until applyGitDates runs.
+func (c *Index) publishDate(data *ParseData) (time.Time, error) {
+	if data.PublishDate != "" {
+		return ParseDate(data.PublishDate, c.Location)
+	}
+	if c.undated == nil {
+		c.undated = make(map[string]int)
+	}
+	c.undated[data.File()] = len(c.all)
+	return data.ModTime().In(c.Location), nil
+}
+
+// applyGitDates dates the posts without a date by the commit adding them to the blog's git repository.
+// The repository is opened once for all posts.
+func (c *Index) applyGitDates(folder string) {
+	if len(c.undated) == 0 {
+		return
+	}
+	files := make([]string, 0, len(c.undated))
+	for file := range c.undated {
+		files = append(files, file)
+	}
+	dates, err := gitDates(folder, files)
+	if err != nil {
+		logrus.WithError(err).Warn("dating posts without a date by their modification time")
+		return
+	}
+	for file, date := range dates {
+		c.all[c.undated[file]].PublishDate = date.In(c.Location)
+	}
+}
+
+// excerpt returns the markdown of the summary or the content before the more separator.
+func excerpt(data *ParseData) string {
+	if data.Summary != "" {
+		return data.Summary
+	}
+	if i := strings.Index(data.Content(), MoreSeparator); i >= 0 {
+		return data.Content()[:i]
+	}
+	return ""
+}
+
 // AddPage creates a new page from the parsed data.
 func (c *Index) AddPage(data *ParseData) error {
 	p := Page{
 		Title:    data.Title,
 		Slug:     data.Slug,
+		TOC:      data.TOC,
 		Content:  data.Content(),
+		ModTime:  data.ModTime(),
+		Aliases:  data.Aliases,
 		Resolver: c.Resolver,
 	}
+	// Pages are loaded by file name, so the page with the earliest file name keeps its slug
+	p.Slug = uniqueSlug(p.Slug, func(slug string) bool {
+		_, ok := c.PageBySlug[slug]
+		return ok
+	})
+	if p.Slug != data.Slug {
+		logrus.WithFields(logrus.Fields{
+			"slug":   data.Slug,
+			"unique": p.Slug,
+		}).Warn("duplicate page slug")
+	}
 
 	c.Pages = append(c.Pages, p)
 	c.PageBySlug[p.Slug] = &p
 	return nil
 }
 
+// NewIndex loads all posts and pages of the blog and returns the index of the posts published by now.
 func NewIndex(cfg *config.Config, resolver URLResolver) (*Index, error) {
 	index := &Index{
-		PostBySlug: make(map[string]*Post),
-		PageBySlug: make(map[string]*Page),
-		Resolver:   resolver,
+		PageBySlug:     make(map[string]*Page),
+		Drafts:         cfg.Drafts,
+		Resolver:       resolver,
+		WordsPerMinute: cfg.Posts.WordsPerMinute,
+		Location:       cfg.Location,
 	}
-	err := loadDirectory(path.Join(cfg.Base, PostsFolder), index.AddPost)
+	renderer, err := NewRenderer(cfg.Markdown.Renderer, cfg.Markdown.Highlight)
+	if err != nil {
+		return nil, fmt.Errorf("new renderer: %w", err)
+	}
+	index.Renderer = renderer
+	err = loadDirectory(path.Join(cfg.Base, PostsFolder), index.AddPost)
 	if err != nil {
 		return nil, fmt.Errorf("load posts dir: %w", err)
 	}
+	index.applyGitDates(cfg.Base)
+	uniquePostSlugs(index.all)
 	// Sort all posts by age
-	sort.Sort(ByAge(index.Posts))
+	sort.Sort(ByAge(index.all))
 	if err := loadDirectory(path.Join(cfg.Base, PagesFolder), index.AddPage); err != nil {
 		return nil, fmt.Errorf("load pages dir: %w", err)
 	}
-	return index, nil
+	return index.At(time.Now()), nil
+}
+
+// At returns a copy of the index with the posts published at the given time.
+func (c *Index) At(now time.Time) *Index {
+	index := &Index{
+		Pages:          c.Pages,
+		PostBySlug:     make(map[string]*Post),
+		PageBySlug:     c.PageBySlug,
+		PostsByTag:     make(map[string][]Post),
+		URLByAlias:     make(map[string]string),
+		Drafts:         c.Drafts,
+		Resolver:       c.Resolver,
+		Renderer:       c.Renderer,
+		WordsPerMinute: c.WordsPerMinute,
+		Location:       c.Location,
+		all:            c.all,
+	}
+	for _, post := range c.all {
+		if !c.Drafts && !post.Published(now) {
+			continue
+		}
+		index.Posts = append(index.Posts, post)
+	}
+	for i := range index.Posts {
+		post := &index.Posts[i]
+		index.PostBySlug[post.Slug] = post
+		// Group posts by tag
+		for _, tag := range post.Tags {
+			index.PostsByTag[tag] = append(index.PostsByTag[tag], *post)
+		}
+		for _, alias := range post.Aliases {
+			index.URLByAlias[AliasPath(alias)] = post.GetURL()
+		}
+	}
+	for _, page := range index.Pages {
+		for _, alias := range page.Aliases {
+			index.URLByAlias[AliasPath(alias)] = page.GetURL()
+		}
+	}
+	index.Search = NewSearchIndex(index.Renderer, index.Posts, index.Pages)
+	return index
+}
+
+// NextPublish returns the earliest publish date after the given time of a scheduled post.
+// If there is no scheduled post, the zero time is returned.
+func (c *Index) NextPublish(now time.Time) time.Time {
+	var next time.Time
+	if c.Drafts {
+		return next
+	}
+	for _, post := range c.all {
+		if post.Draft || !post.PublishDate.After(now) {
+			continue
+		}
+		if next.IsZero() || post.PublishDate.Before(next) {
+			next = post.PublishDate
+		}
+	}
+	return next
 }
 
 // LatestPosts returns a slice of the latest blog posts.
@@ -288,3 +495,49 @@ func (c *Index) LatestPosts(count int) []Post {
 	}
 	return c.Posts[:count]
 }
+
+// Tags returns all tags in alphabetical order.
+func (c *Index) Tags() []string {
+	tags := make([]string, 0, len(c.PostsByTag))
+	for tag := range c.PostsByTag {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// AliasPath normalizes an alias to an absolute path.
+func AliasPath(alias string) string {
+	if !strings.HasPrefix(alias, "/") {
+		return "/" + alias
+	}
+	return alias
+}
+
+// TagName normalizes a tag to its slug, so that it can be used in URLs and file names.
+// Tags without any letters or digits are normalized to an empty name.
+func TagName(tag string) string {
+	return Slugify(tag)
+}
+
+// PageCount returns the number of index pages needed to list all posts.
+func (c *Index) PageCount(size int) int {
+	count := (len(c.Posts) + size - 1) / size
+	if count < 1 {
+		return 1
+	}
+	return count
+}
+
+// PostPage returns the posts on the index page with the given number, starting at one.
+func (c *Index) PostPage(number, size int) []Post {
+	start := (number - 1) * size
+	if start < 0 || start >= len(c.Posts) {
+		return nil
+	}
+	end := start + size
+	if end > len(c.Posts) {
+		end = len(c.Posts)
+	}
+	return c.Posts[start:end]
+}
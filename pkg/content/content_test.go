@@ -0,0 +1,151 @@
+package content
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/lnsp/bloggy/pkg/config"
+)
+
+// testResolver resolves posts to /post/{slug} and pages to /{slug}.
+type testResolver struct{}
+
+func (testResolver) Page(slug string) string                 { return "/" + slug }
+func (testResolver) Post(slug string, date time.Time) string { return "/post/" + slug }
+func (testResolver) Tag(name string) string                  { return "/tag/" + name }
+func (testResolver) Index(number int) string                 { return "/" }
+
+// writeBlog writes the files into a temporary blog folder.
+func writeBlog(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "bloggy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	files[config.DefaultConfigFile] = "meta:\n  url: https://blog.example.com\n"
+	for name, data := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// loadIndex loads the index of a blog folder.
+func loadIndex(t *testing.T, dir string) *Index {
+	cfg, err := config.Load(dir)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	index, err := NewIndex(cfg, testResolver{})
+	if err != nil {
+		t.Fatalf("new index: %v", err)
+	}
+	return index
+}
+
+func TestIndexSlugMatchesURL(t *testing.T) {
+	index := loadIndex(t, writeBlog(t, map[string]string{
+		"posts/release.md":  "---\ntitle: Go 1.15 is released\ndate: 2020-Aug-11\nslug: go-1-15-release\n---\nRelease\n",
+		"posts/Grüße.md":    "---\ntitle: Grüße aus Köln\ndate: 2020-Aug-12\n---\nHallo\n",
+		"pages/Über uns.md": "---\ntitle: Über uns\n---\nWir\n",
+	}))
+	for _, slug := range []string{"go-1-15-release", "grusse"} {
+		post, ok := index.PostBySlug[slug]
+		if !ok {
+			t.Errorf("post %q not found", slug)
+			continue
+		}
+		if url := post.GetURL(); url != "/post/"+slug {
+			t.Errorf("post %q has URL %q, want /post/%s", slug, url, slug)
+		}
+	}
+	page, ok := index.PageBySlug["uber-uns"]
+	if !ok {
+		t.Fatal("page uber-uns not found")
+	}
+	if url := page.GetURL(); url != "/uber-uns" {
+		t.Errorf("page has URL %q, want /uber-uns", url)
+	}
+}
+
+func TestIndexDuplicateSlugs(t *testing.T) {
+	files := map[string]string{
+		"posts/b.md": "---\ntitle: Older\ndate: 2020-Jan-01\nslug: post\n---\nOlder\n",
+		"posts/c.md": "---\ntitle: Newer\ndate: 2020-Feb-01\nslug: post\n---\nNewer\n",
+		"pages/b.md": "---\ntitle: First\nslug: page\n---\nFirst\n",
+		"pages/c.md": "---\ntitle: Second\nslug: page\n---\nSecond\n",
+	}
+	want := map[string]string{"post": "Older", "post-2": "Newer"}
+	check := func(index *Index) {
+		for slug, title := range want {
+			if post, ok := index.PostBySlug[slug]; !ok || post.Title != title {
+				t.Errorf("post %q is %v, want %q", slug, post, title)
+			}
+		}
+		if page, ok := index.PageBySlug["page"]; !ok || page.Title != "First" {
+			t.Errorf("page %q is %v, want First", "page", page)
+		}
+		if page, ok := index.PageBySlug["page-2"]; !ok || page.Title != "Second" {
+			t.Errorf("page %q is %v, want Second", "page-2", page)
+		}
+	}
+	check(loadIndex(t, writeBlog(t, files)))
+
+	// A newer post with an earlier file name does not take over existing slugs
+	files["posts/a.md"] = "---\ntitle: Newest\ndate: 2020-Mar-01\nslug: post\n---\nNewest\n"
+	want["post-3"] = "Newest"
+	check(loadIndex(t, writeBlog(t, files)))
+}
+
+func TestIndexGitDates(t *testing.T) {
+	dir := writeBlog(t, map[string]string{
+		"posts/committed.md": "---\ntitle: Committed\n---\nCommitted\n",
+	})
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worktree.Add("posts/committed.md"); err != nil {
+		t.Fatal(err)
+	}
+	committed := time.Date(2019, time.May, 5, 10, 0, 0, 0, time.UTC)
+	signature := &object.Signature{Name: "Jane Doe", Email: "jane@example.com", When: committed}
+	if _, err := worktree.Commit("Add post", &git.CommitOptions{Author: signature, Committer: signature}); err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Date(2020, time.June, 6, 12, 0, 0, 0, time.UTC)
+	uncommitted := filepath.Join(dir, "posts", "uncommitted.md")
+	if err := ioutil.WriteFile(uncommitted, []byte("---\ntitle: Uncommitted\n---\nUncommitted\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(uncommitted, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+
+	index := loadIndex(t, dir)
+	want := map[string]time.Time{"committed": committed, "uncommitted": modified}
+	for slug, date := range want {
+		post, ok := index.PostBySlug[slug]
+		if !ok {
+			t.Errorf("post %q not found", slug)
+			continue
+		}
+		if !post.PublishDate.Equal(date) {
+			t.Errorf("post %q is dated %v, want %v", slug, post.PublishDate, date)
+		}
+	}
+}
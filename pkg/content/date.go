@@ -0,0 +1,127 @@
+package content
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// DateLayouts are the layouts accepted for the date in a post's header.
+// Dates without a time zone are interpreted in the blog's time zone.
+var DateLayouts = []string{
+	FileDateFormat,
+	"2006-Jan-02 15:04",
+	"2006-Jan-02 15:04:05",
+	"2006-01-02",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04 -0700",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 -0700 MST",
+	"2006-01-02T15:04",
+	"2006-01-02T15:04:05",
+	time.RFC3339,
+	time.RFC3339Nano,
+	"02 Jan 2006",
+	"02 Jan 2006 15:04",
+	"January 2, 2006",
+	"January 2, 2006 15:04",
+	time.RFC1123,
+	time.RFC1123Z,
+}
+
+// ParseDate parses a date in one of the accepted layouts.
+func ParseDate(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range DateLayouts {
+		if date, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", value)
+}
+
+// gitDates returns the dates of the commits adding the files to the git repository containing the folder.
+// Files which are not committed are missing from the dates.
+// If the folder is not inside a repository, no dates are returned.
+func gitDates(folder string, files []string) (map[string]time.Time, error) {
+	repo, err := git.PlainOpenWithOptions(folder, &git.PlainOpenOptions{DetectDotGit: true})
+	if err == git.ErrRepositoryNotExists {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	// Shallow clones lack the commits adding older files
+	if shallow, err := repo.Storer.Shallow(); err != nil {
+		return nil, err
+	} else if len(shallow) > 0 {
+		return nil, errors.New("repository is a shallow clone")
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	root, err := filepath.EvalSymlinks(worktree.Filesystem.Root())
+	if err != nil {
+		return nil, err
+	}
+	// Map the repository paths of the files to the given file names
+	pending := make(map[string]string)
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+			abs = resolved
+		}
+		rel, err := filepath.Rel(root, abs)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		pending[filepath.ToSlash(rel)] = file
+	}
+	head, err := repo.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	commits, err := repo.Log(&git.LogOptions{From: head.Hash()})
+	if err != nil {
+		return nil, err
+	}
+	defer commits.Close()
+	// Walk the history from the newest commit, the oldest commit containing a file added it
+	dates := make(map[string]time.Time)
+	first := true
+	err = commits.ForEach(func(commit *object.Commit) error {
+		tree, err := commit.Tree()
+		if err != nil {
+			return err
+		}
+		for rel, file := range pending {
+			if _, err := tree.FindEntry(rel); err == nil {
+				dates[file] = commit.Committer.When
+			} else if first || !dates[file].IsZero() {
+				delete(pending, rel)
+			}
+		}
+		first = false
+		if len(pending) == 0 {
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dates, nil
+}
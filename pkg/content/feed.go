@@ -0,0 +1,39 @@
+package content
+
+import (
+	"github.com/gorilla/feeds"
+)
+
+// NewFeed either creates a new feed of all blog posts or returns the cached version.
+func (t *Templater) NewFeed() *feeds.Feed {
+	feed, _ := t.load(cacheKeyFeed, func(index *Index) (interface{}, error) {
+		author := &feeds.Author{
+			Name:  t.Config.Author.Name,
+			Email: t.Config.Author.Email,
+		}
+		feed := &feeds.Feed{
+			Title:       t.Config.Meta.Title,
+			Description: t.Config.Meta.Subtitle,
+			Link:        &feeds.Link{Href: t.Config.AbsoluteURL("/")},
+			Author:      author,
+		}
+		for i := range index.Posts {
+			post := &index.Posts[i]
+			url := t.Config.AbsoluteURL(post.GetURL())
+			feed.Add(&feeds.Item{
+				Title:       post.Title,
+				Link:        &feeds.Link{Href: url},
+				Author:      author,
+				Description: post.Subtitle,
+				Id:          url,
+				Created:     post.PublishDate,
+				Content:     index.Renderer.Render(post.GetContent()),
+			})
+		}
+		if len(index.Posts) > 0 {
+			feed.Updated = index.Posts[0].PublishDate
+		}
+		return feed, nil
+	})
+	return feed.(*feeds.Feed)
+}
@@ -0,0 +1,131 @@
+package content
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/chroma"
+	chromahtml "github.com/alecthomas/chroma/formatters/html"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
+	"github.com/russross/blackfriday"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// DefaultHighlightTheme is the syntax highlighting theme used if none is configured.
+const DefaultHighlightTheme = "github"
+
+// highlightClasses matches the class attributes emitted by the highlighter.
+var highlightClasses = func() *regexp.Regexp {
+	classes := []string{"chroma"}
+	for _, class := range chroma.StandardTypes {
+		if class != "" {
+			classes = append(classes, regexp.QuoteMeta(class))
+		}
+	}
+	sort.Strings(classes)
+	class := "(?:" + strings.Join(classes, "|") + ")"
+	return regexp.MustCompile("^" + class + "(?: " + class + ")*$")
+}()
+
+// highlighter turns source code into HTML annotated with CSS classes.
+type highlighter struct {
+	formatter *chromahtml.Formatter
+	style     *chroma.Style
+}
+
+// newHighlighter creates a highlighter using the given theme.
+func newHighlighter(theme string) (*highlighter, error) {
+	if theme == "" {
+		theme = DefaultHighlightTheme
+	}
+	style, ok := styles.Registry[theme]
+	if !ok {
+		return nil, fmt.Errorf("unknown highlight theme '%s'", theme)
+	}
+	return &highlighter{
+		formatter: chromahtml.New(chromahtml.WithClasses(true)),
+		style:     style,
+	}, nil
+}
+
+// highlight writes the highlighted source code to the writer.
+// It reports false and writes nothing if the language is unknown.
+func (h *highlighter) highlight(w io.Writer, source, language string) bool {
+	if language == "" {
+		return false
+	}
+	lexer := lexers.Get(language)
+	if lexer == nil {
+		return false
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, source)
+	if err != nil {
+		return false
+	}
+	var output bytes.Buffer
+	if err := h.formatter.Format(&output, h.style, iterator); err != nil {
+		return false
+	}
+	_, err = output.WriteTo(w)
+	return err == nil
+}
+
+// HighlightStylesheet generates the CSS rules of a highlight theme.
+func HighlightStylesheet(theme string) ([]byte, error) {
+	h, err := newHighlighter(theme)
+	if err != nil {
+		return nil, err
+	}
+	var output bytes.Buffer
+	if err := h.formatter.WriteCSS(&output, h.style); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
+}
+
+// highlightNodeRenderer renders fenced code blocks in Goldmark using the highlighter.
+type highlightNodeRenderer struct {
+	highlighter *highlighter
+}
+
+func (r *highlightNodeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, r.renderFencedCodeBlock)
+}
+
+func (r *highlightNodeRenderer) renderFencedCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	block := node.(*ast.FencedCodeBlock)
+	var code bytes.Buffer
+	lines := block.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		code.Write(line.Value(source))
+	}
+	if !r.highlighter.highlight(w, code.String(), string(block.Language(source))) {
+		w.WriteString("<pre><code>")
+		w.Write(util.EscapeHTML(code.Bytes()))
+		w.WriteString("</code></pre>\n")
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// highlightHTMLRenderer renders fenced code blocks in Blackfriday using the highlighter.
+type highlightHTMLRenderer struct {
+	blackfriday.Renderer
+	highlighter *highlighter
+}
+
+func (r *highlightHTMLRenderer) BlockCode(out *bytes.Buffer, text []byte, lang string) {
+	if !r.highlighter.highlight(out, string(text), lang) {
+		r.Renderer.BlockCode(out, text, lang)
+	}
+}
@@ -0,0 +1,68 @@
+package content
+
+import "sort"
+
+const (
+	// RelatedPostsCount is the maximum number of related posts shown for a post.
+	RelatedPostsCount = 3
+	// RelatedMinScore is the minimum score a post needs to be considered related.
+	RelatedMinScore = 0.1
+)
+
+// Neighbours returns the next older and the next newer published post of the post with the given slug.
+func (c *Index) Neighbours(slug string) (previous, next *Post) {
+	for i := range c.Posts {
+		if c.Posts[i].Slug != slug {
+			continue
+		}
+		if i+1 < len(c.Posts) {
+			previous = &c.Posts[i+1]
+		}
+		if i > 0 {
+			next = &c.Posts[i-1]
+		}
+		break
+	}
+	return previous, next
+}
+
+// RelatedPosts returns the published posts most related to the given post.
+// Posts are scored by their number of shared tags and the similarity of their content.
+func (c *Index) RelatedPosts(post *Post, count int) []Post {
+	tags := make(map[string]bool)
+	for _, tag := range post.Tags {
+		tags[tag] = true
+	}
+	type candidate struct {
+		post  Post
+		score float64
+	}
+	var candidates []candidate
+	for i := range c.Posts {
+		other := &c.Posts[i]
+		if other.Slug == post.Slug {
+			continue
+		}
+		score := c.Search.Similarity(post.GetURL(), other.GetURL())
+		for _, tag := range other.Tags {
+			if tags[tag] {
+				score++
+			}
+		}
+		if score >= RelatedMinScore {
+			candidates = append(candidates, candidate{*other, score})
+		}
+	}
+	// Keep the age order for posts with equal scores
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	if len(candidates) > count {
+		candidates = candidates[:count]
+	}
+	related := make([]Post, len(candidates))
+	for i := range candidates {
+		related[i] = candidates[i].post
+	}
+	return related
+}
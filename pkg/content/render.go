@@ -0,0 +1,163 @@
+package content
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/russross/blackfriday"
+	"github.com/sirupsen/logrus"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+const (
+	// GithubRenderer renders GitHub-flavoured markdown with footnotes, definition lists and heading IDs.
+	GithubRenderer = "github"
+	// CommonRenderer renders markdown using the common Blackfriday extensions.
+	CommonRenderer = "common"
+	// DefaultRenderer is the renderer used if none is configured.
+	DefaultRenderer = GithubRenderer
+)
+
+// Renderer generates sanitized HTML from markdown.
+type Renderer interface {
+	Render(markdown string) string
+}
+
+// NewRenderer creates the renderer with the given name, highlighting code blocks using the given theme.
+func NewRenderer(name, theme string) (Renderer, error) {
+	highlighter, err := newHighlighter(theme)
+	if err != nil {
+		return nil, err
+	}
+	switch name {
+	case "", GithubRenderer:
+		return newGithubRenderer(highlighter), nil
+	case CommonRenderer:
+		return newCommonRenderer(highlighter), nil
+	}
+	return nil, fmt.Errorf("unknown renderer '%s'", name)
+}
+
+// newPolicy creates the sanitizer policy applied to the rendered HTML.
+func newPolicy() *bluemonday.Policy {
+	policy := bluemonday.UGCPolicy()
+	// Allow task list checkboxes
+	policy.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	policy.AllowAttrs("checked", "disabled").OnElements("input")
+	// Allow syntax highlighting classes
+	policy.AllowAttrs("class").Matching(highlightClasses).OnElements("pre", "code", "span")
+	return policy
+}
+
+// githubRenderer renders GitHub-flavoured markdown using Goldmark.
+type githubRenderer struct {
+	markdown goldmark.Markdown
+	policy   *bluemonday.Policy
+}
+
+func newGithubRenderer(highlighter *highlighter) *githubRenderer {
+	return &githubRenderer{
+		markdown: goldmark.New(
+			goldmark.WithExtensions(
+				extension.GFM,
+				extension.Footnote,
+				extension.DefinitionList,
+			),
+			goldmark.WithParserOptions(
+				parser.WithAutoHeadingID(),
+			),
+			goldmark.WithRendererOptions(
+				// Raw HTML is sanitized afterwards
+				html.WithUnsafe(),
+				renderer.WithNodeRenderers(
+					util.Prioritized(&highlightNodeRenderer{highlighter}, 100),
+				),
+			),
+		),
+		policy: newPolicy(),
+	}
+}
+
+func (r *githubRenderer) Render(markdown string) string {
+	var output bytes.Buffer
+	context := parser.NewContext(parser.WithIDs(newHeadingIDs()))
+	if err := r.markdown.Convert([]byte(markdown), &output, parser.WithContext(context)); err != nil {
+		logrus.WithError(err).Warn("failed to render markdown")
+		return ""
+	}
+	return r.policy.Sanitize(output.String())
+}
+
+// headingIDs generates heading IDs the same way as slugs, so that non-ASCII headings keep their letters.
+type headingIDs struct {
+	taken map[string]bool
+}
+
+func newHeadingIDs() *headingIDs {
+	return &headingIDs{taken: make(map[string]bool)}
+}
+
+func (ids *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	slug := Slugify(string(value))
+	if slug == "" {
+		slug = "heading"
+	}
+	slug = uniqueSlug(slug, func(slug string) bool {
+		return ids.taken[slug]
+	})
+	ids.taken[slug] = true
+	return []byte(slug)
+}
+
+func (ids *headingIDs) Put(value []byte) {
+	ids.taken[string(value)] = true
+}
+
+// commonRenderer renders markdown using Blackfriday.
+type commonRenderer struct {
+	renderer blackfriday.Renderer
+	policy   *bluemonday.Policy
+}
+
+// Flags and extensions equal to the ones used by blackfriday.MarkdownCommon,
+// with additional heading IDs for anchors.
+const (
+	commonHTMLFlags = blackfriday.HTML_USE_XHTML |
+		blackfriday.HTML_USE_SMARTYPANTS |
+		blackfriday.HTML_SMARTYPANTS_FRACTIONS |
+		blackfriday.HTML_SMARTYPANTS_DASHES |
+		blackfriday.HTML_SMARTYPANTS_LATEX_DASHES
+	commonExtensions = blackfriday.EXTENSION_NO_INTRA_EMPHASIS |
+		blackfriday.EXTENSION_TABLES |
+		blackfriday.EXTENSION_FENCED_CODE |
+		blackfriday.EXTENSION_AUTOLINK |
+		blackfriday.EXTENSION_STRIKETHROUGH |
+		blackfriday.EXTENSION_SPACE_HEADERS |
+		blackfriday.EXTENSION_HEADER_IDS |
+		blackfriday.EXTENSION_AUTO_HEADER_IDS |
+		blackfriday.EXTENSION_BACKSLASH_LINE_BREAK |
+		blackfriday.EXTENSION_DEFINITION_LISTS
+)
+
+func newCommonRenderer(highlighter *highlighter) *commonRenderer {
+	return &commonRenderer{
+		renderer: &highlightHTMLRenderer{
+			Renderer:    blackfriday.HtmlRenderer(commonHTMLFlags, "", ""),
+			highlighter: highlighter,
+		},
+		policy: newPolicy(),
+	}
+}
+
+func (r *commonRenderer) Render(markdown string) string {
+	output := blackfriday.Markdown([]byte(markdown), r.renderer, commonExtensions)
+	return string(r.policy.SanitizeBytes(output))
+}
@@ -0,0 +1,273 @@
+package content
+
+import (
+	"html"
+	"html/template"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+const (
+	// SearchTitleWeight is the weight of a term occurring in a title.
+	SearchTitleWeight = 3
+	// SearchSubtitleWeight is the weight of a term occurring in a subtitle.
+	SearchSubtitleWeight = 2
+	// SearchBodyWeight is the weight of a term occurring in the body.
+	SearchBodyWeight = 1
+	// SearchSnippetWords is the number of words shown in a result snippet.
+	SearchSnippetWords = 30
+	// SearchResultLimit is the maximum number of search results shown.
+	SearchResultLimit = 20
+)
+
+var whitespace = regexp.MustCompile(`\s+`)
+
+// SearchResult stores a single ranked search hit.
+type SearchResult struct {
+	Title   string        `json:"title"`
+	URL     string        `json:"url"`
+	Snippet template.HTML `json:"snippet"`
+	Score   float64       `json:"score"`
+}
+
+// searchDocument stores the searchable text of a post or page.
+type searchDocument struct {
+	title   string
+	url     string
+	text    string
+	weights map[string]float64
+}
+
+// searchPosting stores the weighted frequency of a term in a document.
+type searchPosting struct {
+	doc    int
+	weight float64
+}
+
+// SearchIndex is an in-memory inverted index over the titles, subtitles and bodies of posts and pages.
+type SearchIndex struct {
+	renderer Renderer
+	docs     []searchDocument
+	docByURL map[string]int
+	postings map[string][]searchPosting
+}
+
+// NewSearchIndex builds a search index of the given posts and pages.
+func NewSearchIndex(renderer Renderer, posts []Post, pages []Page) *SearchIndex {
+	s := &SearchIndex{
+		renderer: renderer,
+		docByURL: make(map[string]int),
+		postings: make(map[string][]searchPosting),
+	}
+	for i := range posts {
+		post := &posts[i]
+		s.add(post, post.Subtitle)
+	}
+	for i := range pages {
+		s.add(&pages[i], "")
+	}
+	return s
+}
+
+// add indexes an entry with an optional subtitle.
+func (s *SearchIndex) add(e Entry, subtitle string) {
+	text := plainText(s.renderer.Render(e.GetContent()))
+	weights := make(map[string]float64)
+	for _, term := range tokenize(e.GetTitle()) {
+		weights[term] += SearchTitleWeight
+	}
+	for _, term := range tokenize(subtitle) {
+		weights[term] += SearchSubtitleWeight
+	}
+	for _, term := range tokenize(text) {
+		weights[term] += SearchBodyWeight
+	}
+	doc := len(s.docs)
+	s.docs = append(s.docs, searchDocument{
+		title:   e.GetTitle(),
+		url:     e.GetURL(),
+		text:    text,
+		weights: weights,
+	})
+	s.docByURL[e.GetURL()] = doc
+	for term, weight := range weights {
+		s.postings[term] = append(s.postings[term], searchPosting{doc, weight})
+	}
+}
+
+// Similarity returns the cosine similarity of the TF-IDF vectors of the documents with the given URLs.
+// Unknown URLs have no similarity to any document.
+func (s *SearchIndex) Similarity(a, b string) float64 {
+	docA, okA := s.docByURL[a]
+	docB, okB := s.docByURL[b]
+	if !okA || !okB {
+		return 0
+	}
+	vectorA, vectorB := s.vector(docA), s.vector(docB)
+	var dot, normA, normB float64
+	for term, weight := range vectorA {
+		dot += weight * vectorB[term]
+		normA += weight * weight
+	}
+	for _, weight := range vectorB {
+		normB += weight * weight
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// vector returns the TF-IDF weights of the terms in a document.
+func (s *SearchIndex) vector(doc int) map[string]float64 {
+	vector := make(map[string]float64, len(s.docs[doc].weights))
+	for term, weight := range s.docs[doc].weights {
+		vector[term] = (1 + math.Log(weight)) * s.idf(term)
+	}
+	return vector
+}
+
+// idf returns the inverse document frequency of a term.
+func (s *SearchIndex) idf(term string) float64 {
+	return math.Log(1 + float64(len(s.docs))/float64(len(s.postings[term])))
+}
+
+// Search returns the documents matching the query ranked by TF-IDF, limited to the given number of results.
+func (s *SearchIndex) Search(query string, limit int) []SearchResult {
+	terms := uniqueTerms(tokenize(query))
+	scores := make(map[int]float64)
+	for _, term := range terms {
+		postings := s.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		idf := s.idf(term)
+		for _, posting := range postings {
+			scores[posting.doc] += (1 + math.Log(posting.weight)) * idf
+		}
+	}
+	results := make([]SearchResult, 0, len(scores))
+	for doc, score := range scores {
+		results = append(results, SearchResult{
+			Title:   s.docs[doc].title,
+			URL:     s.docs[doc].url,
+			Snippet: snippet(s.docs[doc].text, terms),
+			Score:   score,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Title < results[j].Title
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// plainText strips all tags from rendered HTML.
+func plainText(rendered string) string {
+	return html.UnescapeString(bluemonday.StrictPolicy().Sanitize(rendered))
+}
+
+// isSeparator reports whether the rune separates two search terms.
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
+
+// tokenize splits a text into lower-case search terms.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), isSeparator)
+}
+
+// uniqueTerms removes duplicate terms while keeping their order.
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool)
+	unique := terms[:0]
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			unique = append(unique, term)
+		}
+	}
+	return unique
+}
+
+// wordSpans returns the byte offsets of each word in the text.
+func wordSpans(text string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, r := range text {
+		if isSeparator(r) {
+			if start >= 0 {
+				spans = append(spans, [2]int{start, i})
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(text)})
+	}
+	return spans
+}
+
+// snippet extracts an escaped excerpt around the first matching term with all matches highlighted.
+func snippet(text string, terms []string) template.HTML {
+	matches := make(map[string]bool)
+	for _, term := range terms {
+		matches[term] = true
+	}
+	spans := wordSpans(text)
+	if len(spans) == 0 {
+		return ""
+	}
+	first := 0
+	for i, span := range spans {
+		if matches[strings.ToLower(text[span[0]:span[1]])] {
+			first = i
+			break
+		}
+	}
+	start := first - SearchSnippetWords/3
+	if start < 0 {
+		start = 0
+	}
+	end := start + SearchSnippetWords
+	if end > len(spans) {
+		end = len(spans)
+	}
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("… ")
+	}
+	offset := spans[start][0]
+	for _, span := range spans[start:end] {
+		b.WriteString(html.EscapeString(collapseSpace(text[offset:span[0]])))
+		word := html.EscapeString(text[span[0]:span[1]])
+		if matches[strings.ToLower(text[span[0]:span[1]])] {
+			word = "<mark>" + word + "</mark>"
+		}
+		b.WriteString(word)
+		offset = span[1]
+	}
+	if end < len(spans) {
+		b.WriteString(" …")
+	} else {
+		b.WriteString(html.EscapeString(collapseSpace(text[offset:])))
+	}
+	return template.HTML(strings.TrimSpace(b.String()))
+}
+
+// collapseSpace replaces runs of whitespace with a single space.
+func collapseSpace(s string) string {
+	return whitespace.ReplaceAllString(s, " ")
+}
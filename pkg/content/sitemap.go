@@ -0,0 +1,58 @@
+package content
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// SitemapNamespace is the XML namespace of the sitemap protocol.
+const SitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// SitemapDateFormat is the date format used for last modification dates in the sitemap.
+const SitemapDateFormat = "2006-01-02"
+
+// Sitemap lists the URLs of the blog for search engines.
+type Sitemap struct {
+	XMLName   xml.Name     `xml:"urlset"`
+	Namespace string       `xml:"xmlns,attr"`
+	URLs      []SitemapURL `xml:"url"`
+}
+
+// SitemapURL stores a single URL entry of the sitemap.
+type SitemapURL struct {
+	Location     string `xml:"loc"`
+	LastModified string `xml:"lastmod,omitempty"`
+}
+
+// add appends an absolute URL to the sitemap.
+func (s *Sitemap) add(url string, lastModified time.Time) {
+	entry := SitemapURL{Location: url}
+	if !lastModified.IsZero() {
+		entry.LastModified = lastModified.Format(SitemapDateFormat)
+	}
+	s.URLs = append(s.URLs, entry)
+}
+
+// NewSitemap either creates a new sitemap of the index, all posts and all pages or returns the cached version.
+func (t *Templater) NewSitemap() *Sitemap {
+	sitemap, _ := t.load(cacheKeySitemap, func(index *Index) (interface{}, error) {
+		sitemap := &Sitemap{Namespace: SitemapNamespace}
+		var latest time.Time
+		for i := range index.Posts {
+			if modified := index.Posts[i].LastModified(); modified.After(latest) {
+				latest = modified
+			}
+		}
+		sitemap.add(t.Config.AbsoluteURL(index.Resolver.Index(1)), latest)
+		for i := range index.Posts {
+			post := &index.Posts[i]
+			sitemap.add(t.Config.AbsoluteURL(post.GetURL()), post.LastModified())
+		}
+		for i := range index.Pages {
+			page := &index.Pages[i]
+			sitemap.add(t.Config.AbsoluteURL(page.GetURL()), page.ModTime)
+		}
+		return sitemap, nil
+	})
+	return sitemap.(*Sitemap)
+}
@@ -0,0 +1,58 @@
+package content
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rainycape/unidecode"
+	"github.com/sirupsen/logrus"
+)
+
+// slugSeparators matches everything that is neither a lower-case letter nor a digit.
+var slugSeparators = regexp.MustCompile("[^a-z0-9]+")
+
+// Slugify converts a title or file name into a slug usable in URLs.
+// Unicode characters are transliterated to ASCII, e.g. "Grüße aus Köln" becomes "grusse-aus-koln".
+func Slugify(s string) string {
+	s = strings.ToLower(unidecode.Unidecode(s))
+	return strings.Trim(slugSeparators.ReplaceAllString(s, "-"), "-")
+}
+
+// uniqueSlug appends a number to the slug until it is no longer taken.
+func uniqueSlug(slug string, taken func(string) bool) string {
+	unique := slug
+	for n := 2; taken(unique); n++ {
+		unique = slug + "-" + strconv.Itoa(n)
+	}
+	return unique
+}
+
+// uniquePostSlugs appends a number to the slugs of posts sharing their slug with an earlier post.
+// Posts are visited by publish date and then by file name, so the earliest post keeps its slug
+// and adding a post never changes the URLs of existing posts.
+func uniquePostSlugs(posts []Post) {
+	order := make([]int, len(posts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return posts[order[i]].PublishDate.Before(posts[order[j]].PublishDate)
+	})
+	taken := make(map[string]bool)
+	for _, i := range order {
+		post := &posts[i]
+		slug := uniqueSlug(post.Slug, func(slug string) bool {
+			return taken[slug]
+		})
+		if slug != post.Slug {
+			logrus.WithFields(logrus.Fields{
+				"slug":   post.Slug,
+				"unique": slug,
+			}).Warn("duplicate post slug")
+		}
+		taken[slug] = true
+		post.Slug = slug
+	}
+}
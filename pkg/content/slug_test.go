@@ -0,0 +1,58 @@
+package content
+
+import "testing"
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		input, slug string
+	}{
+		{"go-1-15-release", "go-1-15-release"},
+		{"Go 1.15 Release", "go-1-15-release"},
+		{"Grüße aus Köln", "grusse-aus-koln"},
+		{"  Hello,   World!  ", "hello-world"},
+		{"C++ & Go", "c-go"},
+		{"!?.,;", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if slug := Slugify(test.input); slug != test.slug {
+			t.Errorf("Slugify(%q) = %q, want %q", test.input, slug, test.slug)
+		}
+	}
+}
+
+func TestUniqueSlug(t *testing.T) {
+	taken := map[string]bool{"post": true, "post-2": true}
+	tests := []struct {
+		slug, unique string
+	}{
+		{"other", "other"},
+		{"post", "post-3"},
+		{"post-2", "post-2-2"},
+	}
+	for _, test := range tests {
+		unique := uniqueSlug(test.slug, func(slug string) bool { return taken[slug] })
+		if unique != test.unique {
+			t.Errorf("uniqueSlug(%q) = %q, want %q", test.slug, unique, test.unique)
+		}
+	}
+}
+
+func TestTagName(t *testing.T) {
+	tests := []struct {
+		tag, name string
+	}{
+		{"Open Source", "open-source"},
+		{"open-source", "open-source"},
+		{"C#", "c"},
+		{"c/c++", "c-c"},
+		{"what?", "what"},
+		{"Köln", "koln"},
+		{"???", ""},
+	}
+	for _, test := range tests {
+		if name := TagName(test.tag); name != test.name {
+			t.Errorf("TagName(%q) = %q, want %q", test.tag, name, test.name)
+		}
+	}
+}
@@ -5,9 +5,10 @@ import (
 	"fmt"
 	"html/template"
 	"io"
-	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	humanize "github.com/dustin/go-humanize"
@@ -42,11 +43,18 @@ type BaseContext struct {
 // PostContext stores additional information for posts.
 type PostContext struct {
 	BaseContext
-	PostTitle    string
-	PostSubtitle string
-	PostDate     string
-	PostContent  template.HTML
-	PostURL      string
+	PostTitle       string
+	PostSubtitle    string
+	PostDate        string
+	PostContent     template.HTML
+	PostURL         string
+	PostTags        []NavItemContext
+	PostTOC         []TOCEntry
+	PostWordCount   int
+	PostReadingTime int
+	PostPrevious    *Post
+	PostNext        *Post
+	PostRelated     []Post
 }
 
 // PageContext stores additional information for pages.
@@ -55,12 +63,50 @@ type PageContext struct {
 	PageTitle   string
 	PageContent template.HTML
 	PageURL     string
+	PageTOC     []TOCEntry
 }
 
-// IndexContext stores a list of the latest posts.
+// IndexContext stores a page of the latest posts.
 type IndexContext struct {
 	BaseContext
 	LatestPosts []Post
+	PageNumber  int
+	PageCount   int
+	PrevURL     string
+	NextURL     string
+}
+
+// ArchiveContext stores all posts grouped by year and month.
+type ArchiveContext struct {
+	BaseContext
+	ArchiveYears []ArchiveYearContext
+}
+
+// ArchiveYearContext stores the posts of a single year grouped by month.
+type ArchiveYearContext struct {
+	Year   int
+	Months []ArchiveMonthContext
+}
+
+// ArchiveMonthContext stores the posts of a single month.
+type ArchiveMonthContext struct {
+	Month string
+	Posts []Post
+}
+
+// TagContext stores a list of posts sharing a tag.
+type TagContext struct {
+	BaseContext
+	TagName  string
+	TagURL   string
+	TagPosts []Post
+}
+
+// SearchContext stores the results of a search query.
+type SearchContext struct {
+	BaseContext
+	SearchQuery   string
+	SearchResults []SearchResult
 }
 
 // ErrorContext stores error information.
@@ -69,18 +115,35 @@ type ErrorContext struct {
 	Message string
 }
 
+// Templater renders the blog contexts using the display templates.
+// It is safe for concurrent use.
 type Templater struct {
-	Config      *config.Config
-	templates   map[string]*template.Template
+	Config    *config.Config
+	templates map[string]*template.Template
+	cache     *contextCache
+
+	// mu guards the navigation items, the index and the next publish date.
+	mu          sync.RWMutex
 	navItems    []NavItemContext
-	blogContext *BaseContext
-	cachedPages map[string]*PageContext
-	cachedPosts map[string]*PostContext
-	cachedIndex *IndexContext
 	index       *Index
+	nextPublish time.Time
 }
 
+// Cache keys of the created contexts.
+const (
+	cacheKeyBase    = "base"
+	cacheKeyPost    = "post/"
+	cacheKeyPage    = "page/"
+	cacheKeyTag     = "tag/"
+	cacheKeyIndex   = "index/"
+	cacheKeyArchive = "archive"
+	cacheKeyFeed    = "feed"
+	cacheKeySitemap = "sitemap"
+)
+
 func (t *Templater) ClearNav() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.navItems = make([]NavItemContext, 0)
 }
 
@@ -90,76 +153,190 @@ func (t *Templater) AddNavItem(e Entry) {
 		Title: e.GetTitle(),
 		URL:   e.GetURL(),
 	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.navItems = append(t.navItems, item)
 	logrus.WithField("title", item.Title).Debug("added item to navigation bar")
 }
 
 // NewBaseContext either creates a new BaseContext from the global blog configuration or returns the cached version.
 func (t *Templater) NewBaseContext() *BaseContext {
-	if t.blogContext != nil {
-		return t.blogContext
-	}
-	t.blogContext = &BaseContext{
-		BlogTitle:    t.Config.Meta.Title,
-		BlogSubtitle: t.Config.Meta.Subtitle,
-		BlogAuthor:   t.Config.Author.Name,
-		BlogYear:     fmt.Sprint(time.Now().Year()),
-		BlogEmail:    t.Config.Author.Email,
-		BlogURL:      "/",
-		BlogNav:      t.navItems,
-	}
-	return t.blogContext
+	context, _ := t.cache.load(cacheKeyBase, func() (interface{}, error) {
+		t.mu.RLock()
+		defer t.mu.RUnlock()
+		return &BaseContext{
+			BlogTitle:    t.Config.Meta.Title,
+			BlogSubtitle: t.Config.Meta.Subtitle,
+			BlogAuthor:   t.Config.Author.Name,
+			BlogYear:     fmt.Sprint(time.Now().Year()),
+			BlogEmail:    t.Config.Author.Email,
+			BlogURL:      t.Config.AbsoluteURL("/"),
+			BlogNav:      t.navItems,
+		}, nil
+	})
+	return context.(*BaseContext)
 }
 
 // NewPostContext either creates a new post context or returns the cached version.
 func (t *Templater) NewPostContext(slug string) (*PostContext, error) {
-	context, ok := t.cachedPosts[slug]
-	if !ok {
-		post, ok := t.index.PostBySlug[slug]
+	slug = Slugify(slug)
+	context, err := t.load(cacheKeyPost+slug, func(index *Index) (interface{}, error) {
+		post, ok := index.PostBySlug[slug]
 		if !ok {
 			return nil, errors.New("post not found")
 		}
-		context = &PostContext{
+		rendered := index.Renderer.Render(post.GetContent())
+		context := &PostContext{
 			*t.NewBaseContext(),
 			post.Title,
 			post.Subtitle,
 			humanize.Time(post.PublishDate),
-			template.HTML(Render(post)),
+			template.HTML(rendered),
 			post.GetURL(),
+			newTagItems(index, post.Tags),
+			nil,
+			post.WordCount,
+			post.ReadingTime,
+			nil,
+			nil,
+			index.RelatedPosts(post, RelatedPostsCount),
+		}
+		context.PostPrevious, context.PostNext = index.Neighbours(slug)
+		if post.TOC {
+			context.PostTOC = TableOfContents(rendered)
 		}
-		t.cachedPosts[slug] = context
 		logrus.WithField("slug", slug).Debug("created cache version of post")
+		return context, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return context, nil
+	return context.(*PostContext), nil
 }
 
 // NewPageContext either creates a new page context or returns the cached version.
 func (t *Templater) NewPageContext(slug string) (*PageContext, error) {
-	context, ok := t.cachedPages[slug]
-	if !ok {
-		page, ok := t.index.PageBySlug[slug]
+	slug = Slugify(slug)
+	context, err := t.load(cacheKeyPage+slug, func(index *Index) (interface{}, error) {
+		page, ok := index.PageBySlug[slug]
 		if !ok {
 			return nil, errors.New("page '" + slug + "' not found")
 		}
-		context = &PageContext{
+		rendered := index.Renderer.Render(page.GetContent())
+		context := &PageContext{
 			*t.NewBaseContext(),
 			page.Title,
-			template.HTML(Render(page)),
+			template.HTML(rendered),
 			page.GetURL(),
+			nil,
+		}
+		if page.TOC {
+			context.PageTOC = TableOfContents(rendered)
 		}
-		t.cachedPages[slug] = context
 		logrus.WithField("slug", slug).Debug("created cache version of page")
+		return context, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return context.(*PageContext), nil
+}
+
+// NewIndexContext either creates a new context for the index page with the given number or returns the cached version.
+func (t *Templater) NewIndexContext(number int) (*IndexContext, error) {
+	context, err := t.load(cacheKeyIndex+strconv.Itoa(number), func(index *Index) (interface{}, error) {
+		size := t.Config.Posts.PageSize
+		count := index.PageCount(size)
+		if number < 1 || number > count {
+			return nil, fmt.Errorf("index page %d not found", number)
+		}
+		context := &IndexContext{
+			BaseContext: *t.NewBaseContext(),
+			LatestPosts: index.PostPage(number, size),
+			PageNumber:  number,
+			PageCount:   count,
+		}
+		if number > 1 {
+			context.PrevURL = index.Resolver.Index(number - 1)
+		}
+		if number < count {
+			context.NextURL = index.Resolver.Index(number + 1)
+		}
+		logrus.WithField("page", number).Debug("created cache version of index")
+		return context, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return context.(*IndexContext), nil
+}
+
+// NewArchiveContext either creates a new archive context or returns the cached version.
+func (t *Templater) NewArchiveContext() *ArchiveContext {
+	context, _ := t.load(cacheKeyArchive, func(index *Index) (interface{}, error) {
+		context := &ArchiveContext{BaseContext: *t.NewBaseContext()}
+		for _, post := range index.Posts {
+			year, month := post.PublishDate.Year(), post.PublishDate.Month().String()
+			years := len(context.ArchiveYears)
+			if years == 0 || context.ArchiveYears[years-1].Year != year {
+				context.ArchiveYears = append(context.ArchiveYears, ArchiveYearContext{Year: year})
+				years++
+			}
+			current := &context.ArchiveYears[years-1]
+			months := len(current.Months)
+			if months == 0 || current.Months[months-1].Month != month {
+				current.Months = append(current.Months, ArchiveMonthContext{Month: month})
+				months++
+			}
+			current.Months[months-1].Posts = append(current.Months[months-1].Posts, post)
+		}
+		return context, nil
+	})
+	return context.(*ArchiveContext)
+}
+
+// NewTagContext either creates a new tag context or returns the cached version.
+func (t *Templater) NewTagContext(name string) (*TagContext, error) {
+	name = TagName(name)
+	context, err := t.load(cacheKeyTag+name, func(index *Index) (interface{}, error) {
+		posts, ok := index.PostsByTag[name]
+		if !ok {
+			return nil, errors.New("tag '" + name + "' not found")
+		}
+		logrus.WithField("tag", name).Debug("created cache version of tag")
+		return &TagContext{
+			*t.NewBaseContext(),
+			name,
+			index.Resolver.Tag(name),
+			posts,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return context, nil
+	return context.(*TagContext), nil
 }
 
-// NewIndexContext either creates a new index context or returns the cached version.
-func (t *Templater) NewIndexContext() *IndexContext {
-	if t.cachedIndex != nil {
-		return t.cachedIndex
+// newTagItems creates a link for each of the given tags.
+func newTagItems(index *Index, tags []string) []NavItemContext {
+	items := make([]NavItemContext, len(tags))
+	for i, tag := range tags {
+		items[i] = NavItemContext{
+			Title: tag,
+			URL:   index.Resolver.Tag(tag),
+		}
+	}
+	return items
+}
+
+// NewSearchContext creates a new context with the ranked results of the query.
+func (t *Templater) NewSearchContext(query string) *SearchContext {
+	index := t.Index()
+	return &SearchContext{
+		*t.NewBaseContext(),
+		query,
+		index.Search.Search(query, SearchResultLimit),
 	}
-	t.cachedIndex = &IndexContext{*t.NewBaseContext(), t.index.LatestPosts(10)}
-	return t.cachedIndex
 }
 
 // NewErrorContext creates a new error context.
@@ -168,28 +345,36 @@ func (t *Templater) NewErrorContext(err error) *ErrorContext {
 }
 
 // NewTemplater loads the templates from the blog folder.
+// Templates missing in the blog folder are loaded from the default theme.
 func NewTemplater(cfg *config.Config, index *Index) (*Templater, error) {
 	tmpl := &Templater{
 		Config:      cfg,
-		cachedPages: make(map[string]*PageContext),
-		cachedPosts: make(map[string]*PostContext),
+		cache:       newContextCache(),
 		templates:   make(map[string]*template.Template),
 		index:       index,
+		nextPublish: index.NextPublish(time.Now()),
 	}
-	displays, err := filepath.Glob(path.Join(cfg.Base, TemplateFolder, DisplayFolder, "*.html"))
+	displays, err := templateSources(cfg, DisplayFolder)
 	if err != nil {
-		return nil, fmt.Errorf("displays glob: %w", err)
+		return nil, fmt.Errorf("load displays: %w", err)
 	}
-	logrus.WithField("displays", displays).Debug("loading displays")
-	includes, err := filepath.Glob(path.Join(cfg.Base, TemplateFolder, IncludeFolder, "*.html"))
+	includes, err := templateSources(cfg, IncludeFolder)
 	if err != nil {
-		return nil, fmt.Errorf("includes glob: %w", err)
+		return nil, fmt.Errorf("load includes: %w", err)
 	}
-	logrus.WithField("includes", includes).Debug("loading includes")
 	for _, display := range displays {
-		files := append(includes, display)
-		name := strings.TrimSuffix(filepath.Base(display), filepath.Ext(display))
-		tmpl.templates[name] = template.Must(template.New(name).ParseFiles(files...))
+		name := strings.TrimSuffix(display.File, filepath.Ext(display.File))
+		parsed := template.New(name)
+		// Includes are parsed theme first, so that blog definitions override theme definitions
+		for _, include := range includes {
+			if _, err := parsed.New(include.File).Parse(include.Source); err != nil {
+				return nil, fmt.Errorf("parse include %s: %w", include.File, err)
+			}
+		}
+		if _, err := parsed.New(display.File).Parse(display.Source); err != nil {
+			return nil, fmt.Errorf("parse display %s: %w", name, err)
+		}
+		tmpl.templates[name] = parsed
 	}
 	for _, page := range index.Pages {
 		tmpl.AddNavItem(&page)
@@ -201,6 +386,32 @@ func NewTemplater(cfg *config.Config, index *Index) (*Templater, error) {
 	return tmpl, nil
 }
 
+// Index returns the content index used by the templater.
+// Scheduled posts which are due by now are published first.
+func (t *Templater) Index() *Index {
+	now := time.Now()
+	t.mu.RLock()
+	index, due := t.index, !t.nextPublish.IsZero() && !now.Before(t.nextPublish)
+	t.mu.RUnlock()
+	if !due {
+		return index
+	}
+	t.mu.Lock()
+	// Another request may have published the posts in the meantime
+	published := !t.nextPublish.IsZero() && !now.Before(t.nextPublish)
+	if published {
+		logrus.WithField("date", t.nextPublish).Info("publishing scheduled posts")
+		t.index = t.index.At(now)
+		t.nextPublish = t.index.NextPublish(now)
+	}
+	index = t.index
+	t.mu.Unlock()
+	if published {
+		t.ClearCache()
+	}
+	return index
+}
+
 // RenderPage renders a page or throws an error if the template is missing.
 func (t *Templater) RenderPage(w io.Writer, name string, context interface{}) error {
 	tmpl, ok := t.templates[name]
@@ -210,13 +421,21 @@ func (t *Templater) RenderPage(w io.Writer, name string, context interface{}) er
 	return tmpl.ExecuteTemplate(w, "base", context)
 }
 
+// load returns the cached value of the key or creates it from the current index.
+// The index is looked up after the cache generation is read, so that values created
+// from an index replaced in the meantime by publishing scheduled posts are never stored.
+func (t *Templater) load(key string, create func(index *Index) (interface{}, error)) (interface{}, error) {
+	// Publish due posts before looking up the cache
+	t.Index()
+	return t.cache.load(key, func() (interface{}, error) {
+		return create(t.Index())
+	})
+}
+
 // ClearCache clears the context cache.
 func (t *Templater) ClearCache() {
 	logrus.Info("clearing cache")
-	t.blogContext = nil
-	t.cachedPages = make(map[string]*PageContext)
-	t.cachedPosts = make(map[string]*PostContext)
-	t.cachedIndex = nil
+	t.cache.clear()
 }
 
 type NavigationLink struct {
@@ -0,0 +1,79 @@
+package content
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/lnsp/bloggy/pkg/config"
+)
+
+func TestTemplaterIncludesOverrideTheme(t *testing.T) {
+	// app.html sorts before the theme's base.html, but must still win
+	dir := writeBlog(t, map[string]string{
+		"templates/includes/app.html": `{{define "base"}}custom{{end}}`,
+	})
+	cfg, err := config.Load(dir)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	templater, err := NewTemplater(cfg, loadIndex(t, dir))
+	if err != nil {
+		t.Fatalf("new templater: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := templater.RenderPage(&buf, "archive", templater.NewArchiveContext()); err != nil {
+		t.Fatalf("render archive: %v", err)
+	}
+	if got := buf.String(); got != "custom" {
+		t.Errorf("rendered %q, want %q", got, "custom")
+	}
+}
+
+func TestTemplaterPublishClearsContexts(t *testing.T) {
+	dir := writeBlog(t, map[string]string{
+		"posts/first.md":  "---\ntitle: First\ndate: 2020-Jan-01\n---\nFirst\n",
+		"posts/second.md": "---\ntitle: Second\ndate: 2020-Feb-01\n---\nSecond\n",
+	})
+	cfg, err := config.Load(dir)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	templater, err := NewTemplater(cfg, loadIndex(t, dir))
+	if err != nil {
+		t.Fatalf("new templater: %v", err)
+	}
+	// Go back to the time before the second post was published
+	before := time.Date(2020, time.January, 15, 0, 0, 0, 0, time.UTC)
+	schedule := func(due bool) {
+		templater.mu.Lock()
+		defer templater.mu.Unlock()
+		templater.index = templater.index.At(before)
+		templater.nextPublish = time.Time{}
+		if due {
+			templater.nextPublish = templater.index.NextPublish(before)
+		}
+	}
+	schedule(false)
+	if posts := len(templater.NewFeed().Items); posts != 1 {
+		t.Fatalf("feed has %d posts before publishing, want 1", posts)
+	}
+	// Publishing while a context is created must not store the context of the old index
+	count := func(index *Index) (interface{}, error) {
+		return len(index.Posts), nil
+	}
+	created, _ := templater.load("posts", func(index *Index) (interface{}, error) {
+		schedule(true)
+		templater.Index()
+		return count(index)
+	})
+	if created != 1 {
+		t.Fatalf("created context from %d posts, want 1", created)
+	}
+	if cached, _ := templater.load("posts", count); cached != 2 {
+		t.Errorf("cached context has %d posts, want 2", cached)
+	}
+	if posts := len(templater.NewFeed().Items); posts != 2 {
+		t.Errorf("feed has %d posts after publishing, want 2", posts)
+	}
+}
@@ -0,0 +1,64 @@
+package content
+
+import (
+	"embed"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+
+	"github.com/lnsp/bloggy/pkg/config"
+	"github.com/sirupsen/logrus"
+)
+
+// themeFolder is the folder of the default theme inside the embedded files.
+// The theme provides all templates missing in the blog folder.
+const themeFolder = "theme"
+
+//go:embed theme
+var theme embed.FS
+
+// templateSource is a template file and its contents.
+type templateSource struct {
+	File   string
+	Source string
+}
+
+// templateSources loads the templates of a template subfolder.
+// The theme templates come first and the blog templates after them, each sorted by file name,
+// so that definitions in the blog folder win over the theme when parsed in order.
+// A blog template replaces the theme template with the same file name.
+func templateSources(cfg *config.Config, folder string) ([]templateSource, error) {
+	files, err := filepath.Glob(filepath.Join(cfg.Base, TemplateFolder, folder, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	blog := make(map[string]bool, len(files))
+	for _, file := range files {
+		blog[filepath.Base(file)] = true
+	}
+	embedded, err := fs.Glob(theme, path.Join(themeFolder, folder, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	var sources []templateSource
+	for _, file := range embedded {
+		if blog[path.Base(file)] {
+			continue
+		}
+		source, err := theme.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, templateSource{File: path.Base(file), Source: string(source)})
+	}
+	for _, file := range files {
+		source, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, templateSource{File: filepath.Base(file), Source: string(source)})
+		logrus.WithField("file", file).Debug("overriding theme template")
+	}
+	return sources, nil
+}
@@ -0,0 +1,82 @@
+package content
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// TOCEntry stores a heading in the table of contents of a post or page.
+type TOCEntry struct {
+	Title    string
+	URL      string
+	Level    int
+	Children []TOCEntry
+}
+
+// headingLevels maps each heading element to its level.
+var headingLevels = map[string]int{
+	"h1": 1,
+	"h2": 2,
+	"h3": 3,
+	"h4": 4,
+	"h5": 5,
+	"h6": 6,
+}
+
+// TableOfContents builds a nested table of contents from the headings with an ID in the rendered HTML.
+func TableOfContents(rendered string) []TOCEntry {
+	root, err := html.Parse(strings.NewReader(rendered))
+	if err != nil {
+		return nil
+	}
+	var headings []TOCEntry
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if level, ok := headingLevels[node.Data]; ok && node.Type == html.ElementNode {
+			for _, attr := range node.Attr {
+				if attr.Key == "id" && attr.Val != "" {
+					headings = append(headings, TOCEntry{
+						Title: strings.TrimSpace(textContent(node)),
+						URL:   "#" + attr.Val,
+						Level: level,
+					})
+				}
+			}
+			return
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+	return nestTOC(headings)
+}
+
+// nestTOC nests each heading below the closest preceding heading with a lower level.
+func nestTOC(headings []TOCEntry) []TOCEntry {
+	var entries []TOCEntry
+	for i := 0; i < len(headings); {
+		entry := headings[i]
+		j := i + 1
+		for j < len(headings) && headings[j].Level > entry.Level {
+			j++
+		}
+		entry.Children = nestTOC(headings[i+1 : j])
+		entries = append(entries, entry)
+		i = j
+	}
+	return entries
+}
+
+// textContent returns the concatenated text of a node and its descendants.
+func textContent(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+	var b strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(textContent(child))
+	}
+	return b.String()
+}
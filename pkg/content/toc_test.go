@@ -0,0 +1,25 @@
+package content
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTableOfContentsHeadingIDs(t *testing.T) {
+	renderer, err := NewRenderer(GithubRenderer, "")
+	if err != nil {
+		t.Fatalf("new renderer: %v", err)
+	}
+	rendered := renderer.Render("## Über Größe\n\n### Détails\n\n## Über Größe\n\n## 日本\n\n## !!!\n")
+	want := []TOCEntry{
+		{Title: "Über Größe", URL: "#uber-grosse", Level: 2, Children: []TOCEntry{
+			{Title: "Détails", URL: "#details", Level: 3},
+		}},
+		{Title: "Über Größe", URL: "#uber-grosse-2", Level: 2},
+		{Title: "日本", URL: "#ri-ben", Level: 2},
+		{Title: "!!!", URL: "#heading", Level: 2},
+	}
+	if got := TableOfContents(rendered); !reflect.DeepEqual(got, want) {
+		t.Errorf("TableOfContents() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,38 @@
+package content
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// CountWords counts the words in the rendered HTML, ignoring code blocks.
+func CountWords(rendered string) int {
+	root, err := html.Parse(strings.NewReader(rendered))
+	if err != nil {
+		return 0
+	}
+	count := 0
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		switch {
+		case node.Type == html.ElementNode && node.Data == "pre":
+			return
+		case node.Type == html.TextNode:
+			count += len(strings.Fields(node.Data))
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+	return count
+}
+
+// ReadingTime estimates the minutes needed to read the given number of words, rounded up.
+func ReadingTime(words, wordsPerMinute int) int {
+	if words == 0 || wordsPerMinute <= 0 {
+		return 0
+	}
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
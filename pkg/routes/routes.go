@@ -1,12 +1,26 @@
 package routes
 
 import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
 	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/gorilla/feeds"
 	"github.com/gorilla/mux"
 	"github.com/lnsp/bloggy/pkg/config"
 	"github.com/lnsp/bloggy/pkg/content"
@@ -16,39 +30,125 @@ import (
 const (
 	// IndexBaseURL for routing index requests.
 	IndexBaseURL = "/"
+	// IndexPageBaseURL for routing numbered index page requests.
+	IndexPageBaseURL = "/page/"
+	// ArchiveURL for routing archive requests.
+	ArchiveURL = "/archive"
 	// PageBaseURL for routing page requests.
 	PageBaseURL = "/"
-	// PostBaseURL for routing post requests.
-	PostBaseURL = "/post/"
+	// TagBaseURL for routing tag requests.
+	TagBaseURL = "/tag/"
+	// FeedAtomURL for routing Atom feed requests.
+	FeedAtomURL = "/feed.atom"
+	// FeedRSSURL for routing RSS feed requests.
+	FeedRSSURL = "/feed.rss"
+	// SearchURL for routing search requests.
+	SearchURL = "/search"
+	// SearchJSONURL for routing search requests answered with JSON.
+	SearchJSONURL = "/search.json"
+	// HighlightURL for routing syntax highlighting stylesheet requests.
+	HighlightURL = "/highlight.css"
+	// SitemapURL for routing sitemap requests.
+	SitemapURL = "/sitemap.xml"
+	// RobotsURL for routing robots.txt requests.
+	RobotsURL = "/robots.txt"
 	// AssetBaseURL for routing asset requests.
 	StaticBaseURL  = "/static/"
 	FaviconBaseURL = "/favicon.ico"
 	StaticFolder   = "static"
+	// SaveIndexFile is the file name used for folder routes when saving.
+	SaveIndexFile = "index.html"
+	// SaveErrorFile is the file name of the rendered error page when saving.
+	SaveErrorFile = "404.html"
 )
 
+// redirectPage is the page stored in place of a redirect when saving.
+const redirectPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Redirecting to %s</title>
+<link rel="canonical" href="%s">
+<meta http-equiv="refresh" content="0; url=%s">
+</head>
+</html>
+`
+
 type Router struct {
+	mu        sync.RWMutex
 	mux       *mux.Router
 	templater *content.Templater
 	config    *config.Config
 }
 
+// current returns the router state currently in use.
+func (router *Router) current() (*mux.Router, *content.Templater, *config.Config) {
+	router.mu.RLock()
+	defer router.mu.RUnlock()
+	return router.mux, router.templater, router.config
+}
+
+// Reload swaps the config and templater of the router and registers the routes again.
+// Requests in flight keep using the previous state.
+func (router *Router) Reload(cfg *config.Config, templater *content.Templater) {
+	routes := router.routes(cfg)
+	router.mu.Lock()
+	defer router.mu.Unlock()
+	router.mux = routes
+	router.templater = templater
+	router.config = cfg
+}
+
+// ServeHTTP dispatches the request to the currently registered routes.
+func (router *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	routes, _, _ := router.current()
+	routes.ServeHTTP(w, r)
+}
+
 // ErrorHandler handles the errors.
 func (router *Router) error(w http.ResponseWriter, err error, status int) {
 	w.WriteHeader(status)
 
 	logrus.WithError(err).Error("failed to render page")
-	err = router.templater.RenderPage(w, "error", router.templater.NewErrorContext(err))
+	_, templater, _ := router.current()
+	err = templater.RenderPage(w, "error", templater.NewErrorContext(err))
 	if err != nil {
 		fmt.Fprintln(w, err.Error())
 		return
 	}
 }
 
-// IndexHandler handles the index page and displays a list of the recent blog posts.
+// IndexHandler handles the index pages and displays a list of the recent blog posts.
 func (router *Router) indexHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		err := router.templater.RenderPage(w, "index", router.templater.NewIndexContext())
+		vars := mux.Vars(r)
+		number := 1
+		if page, ok := vars["page"]; ok {
+			var err error
+			if number, err = strconv.Atoi(page); err != nil {
+				router.error(w, err, 404)
+				return
+			}
+		}
+		_, templater, _ := router.current()
+		context, err := templater.NewIndexContext(number)
 		if err != nil {
+			router.error(w, err, 404)
+			return
+		}
+		err = templater.RenderPage(w, "index", context)
+		if err != nil {
+			router.error(w, err, 500)
+			return
+		}
+	})
+}
+
+// ArchiveHandler handles the archive page and displays all blog posts.
+func (router *Router) archiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, templater, _ := router.current()
+		if err := templater.RenderPage(w, "archive", templater.NewArchiveContext()); err != nil {
 			router.error(w, err, 500)
 			return
 		}
@@ -59,12 +159,18 @@ func (router *Router) indexHandler() http.Handler {
 func (router *Router) postHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
-		context, err := router.templater.NewPostContext(vars["slug"])
+		_, templater, _ := router.current()
+		context, err := templater.NewPostContext(vars["slug"])
 		if err != nil {
 			router.error(w, err, 404)
 			return
 		}
-		err = router.templater.RenderPage(w, "post", context)
+		// Send requests with a mismatching date to the canonical post URL
+		if r.URL.Path != context.PostURL {
+			http.Redirect(w, r, context.PostURL, http.StatusMovedPermanently)
+			return
+		}
+		err = templater.RenderPage(w, "post", context)
 		if err != nil {
 			router.error(w, err, 500)
 			return
@@ -76,58 +182,432 @@ func (router *Router) postHandler() http.Handler {
 func (router *Router) pageHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
-		context, err := router.templater.NewPageContext(vars["slug"])
+		_, templater, _ := router.current()
+		context, err := templater.NewPageContext(vars["slug"])
 		if err != nil {
 			router.error(w, err, 404)
 			return
 		}
-		if err := router.templater.RenderPage(w, "page", context); err != nil {
+		if err := templater.RenderPage(w, "page", context); err != nil {
 			router.error(w, err, 500)
 			return
 		}
 	})
 }
 
+// TagHandler handles a tag request and displays the posts sharing the tag.
+func (router *Router) tagHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		_, templater, _ := router.current()
+		context, err := templater.NewTagContext(vars["name"])
+		if err != nil {
+			router.error(w, err, 404)
+			return
+		}
+		if err := templater.RenderPage(w, "tag", context); err != nil {
+			router.error(w, err, 500)
+			return
+		}
+	})
+}
+
+// FeedHandler handles a feed request and writes the posts in the given format.
+func (router *Router) feedHandler(contentType string, write func(*feeds.Feed, io.Writer) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, templater, _ := router.current()
+		w.Header().Set("Content-Type", contentType)
+		if err := write(templater.NewFeed(), w); err != nil {
+			router.error(w, err, 500)
+			return
+		}
+	})
+}
+
+// SearchHandler handles a search request and displays the matching posts and pages.
+func (router *Router) searchHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, templater, _ := router.current()
+		context := templater.NewSearchContext(r.URL.Query().Get("q"))
+		if err := templater.RenderPage(w, "search", context); err != nil {
+			router.error(w, err, 500)
+			return
+		}
+	})
+}
+
+// SearchJSONHandler handles a search request and writes the matching posts and pages as JSON.
+func (router *Router) searchJSONHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, templater, _ := router.current()
+		context := templater.NewSearchContext(r.URL.Query().Get("q"))
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		err := json.NewEncoder(w).Encode(struct {
+			Query   string                 `json:"query"`
+			Results []content.SearchResult `json:"results"`
+		}{context.SearchQuery, context.SearchResults})
+		if err != nil {
+			logrus.WithError(err).Error("failed to encode search results")
+		}
+	})
+}
+
+// SitemapHandler handles a sitemap request and lists all blog URLs.
+func (router *Router) sitemapHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, templater, _ := router.current()
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		io.WriteString(w, xml.Header)
+		if err := xml.NewEncoder(w).Encode(templater.NewSitemap()); err != nil {
+			router.error(w, err, 500)
+			return
+		}
+	})
+}
+
+// RobotsHandler handles a robots.txt request and points crawlers to the sitemap.
+func (router *Router) robotsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, cfg := router.current()
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, "User-agent: *")
+		if len(cfg.Robots.Disallow) == 0 {
+			fmt.Fprintln(w, "Disallow:")
+		}
+		for _, disallow := range cfg.Robots.Disallow {
+			fmt.Fprintln(w, "Disallow:", disallow)
+		}
+		fmt.Fprintln(w, "Sitemap:", cfg.AbsoluteURL(SitemapURL))
+	})
+}
+
+// HighlightHandler handles a stylesheet request and writes the CSS of the highlight theme.
+func (router *Router) highlightHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, cfg := router.current()
+		stylesheet, err := content.HighlightStylesheet(cfg.Markdown.Highlight)
+		if err != nil {
+			router.error(w, err, 500)
+			return
+		}
+		w.Header().Set("Content-Type", "text/css; charset=utf-8")
+		w.Write(stylesheet)
+	})
+}
+
 // FaviconHandler initializes a new favicon handler.
 func (router *Router) faviconHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, filepath.Join(router.config.Base, router.config.Meta.Favicon))
+		_, _, cfg := router.current()
+		http.ServeFile(w, r, filepath.Join(cfg.Base, cfg.Meta.Favicon))
 	})
 }
 
+// URLs lists the URLs of all rendered routes, excluding static files and redirects.
+func (router *Router) URLs() []string {
+	_, templater, cfg := router.current()
+	index := templater.Index()
+	urls := []string{ArchiveURL, FeedAtomURL, FeedRSSURL, HighlightURL, SitemapURL, RobotsURL}
+	for number := 1; number <= index.PageCount(cfg.Posts.PageSize); number++ {
+		urls = append(urls, index.Resolver.Index(number))
+	}
+	for _, post := range index.Posts {
+		urls = append(urls, post.GetURL())
+	}
+	for _, page := range index.Pages {
+		urls = append(urls, page.GetURL())
+	}
+	for _, tag := range index.Tags() {
+		urls = append(urls, index.Resolver.Tag(tag))
+	}
+	return urls
+}
+
 // Save renders all routes statically to a local directory.
 func (router *Router) Save(dir string) error {
+	routes, templater, cfg := router.current()
+	for _, url := range router.URLs() {
+		if err := saveURL(routes, dir, url); err != nil {
+			return fmt.Errorf("save %s: %w", url, err)
+		}
+	}
+	for from, to := range redirects(cfg, templater) {
+		if err := saveRedirect(dir, from, to); err != nil {
+			return fmt.Errorf("save redirect %s: %w", from, err)
+		}
+	}
+	if err := saveError(templater, dir); err != nil {
+		return fmt.Errorf("save error page: %w", err)
+	}
+	if err := copyDir(path.Join(cfg.Base, StaticFolder), path.Join(dir, StaticFolder)); err != nil {
+		return fmt.Errorf("copy static: %w", err)
+	}
+	if cfg.Meta.Favicon != "" {
+		err := copyFile(filepath.Join(cfg.Base, cfg.Meta.Favicon), filepath.Join(dir, FaviconBaseURL))
+		if err != nil {
+			return fmt.Errorf("copy favicon: %w", err)
+		}
+	}
 	return nil
 }
 
-// Serve waits for incoming connections on the configured port.
-func (router *Router) Serve() error {
-	server := &http.Server{
-		Addr:              fmt.Sprintf(":%d", router.config.Server.Port),
+// saveURL renders a single route and stores the response in the output directory.
+// Routes without a file extension are stored as an index.html inside a folder of the same name.
+func saveURL(routes http.Handler, dir, url string) error {
+	recorder := httptest.NewRecorder()
+	routes.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, url, nil))
+	if recorder.Code != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", recorder.Code)
+	}
+	file := filepath.Join(dir, filepath.FromSlash(url))
+	if path.Ext(url) == "" {
+		file = filepath.Join(file, SaveIndexFile)
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	logrus.WithFields(logrus.Fields{
+		"url":  url,
+		"file": file,
+	}).Debug("saving route")
+	return ioutil.WriteFile(file, recorder.Body.Bytes(), 0644)
+}
+
+// saveRedirect stores a page redirecting to the given URL, since static hosts can not send redirects themselves.
+func saveRedirect(dir, from, to string) error {
+	file := filepath.Join(dir, filepath.FromSlash(from))
+	if path.Ext(from) == "" {
+		file = filepath.Join(file, SaveIndexFile)
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	target := html.EscapeString(to)
+	page := fmt.Sprintf(redirectPage, target, target, target)
+	return ioutil.WriteFile(file, []byte(page), 0644)
+}
+
+// saveError renders the error page used for unknown routes.
+func saveError(templater *content.Templater, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(filepath.Join(dir, SaveErrorFile))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return templater.RenderPage(file, "error", templater.NewErrorContext(errors.New("page not found")))
+}
+
+// copyDir recursively copies a directory, skipping it if it does not exist.
+func copyDir(src, dst string) error {
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.Walk(src, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, file)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0755)
+		}
+		return copyFile(file, filepath.Join(dst, rel))
+	})
+}
+
+// copyFile copies a single file, creating the parent directories if needed.
+func copyFile(src, dst string) error {
+	input, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	output, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(output, input); err != nil {
+		output.Close()
+		return err
+	}
+	return output.Close()
+}
+
+// Serve waits for incoming connections on the configured port until the context is done.
+// If a certificate and key are configured, it also serves HTTPS on the configured TLS port.
+// Once the context is done, open connections are drained within the configured shutdown timeout.
+func (router *Router) Serve(ctx context.Context) error {
+	_, _, cfg := router.current()
+	var servers []*http.Server
+	errs := make(chan error, 2)
+	var handler http.Handler = router
+	if cfg.Server.TLSPort != 0 && cfg.Server.Certificate != "" && cfg.Server.Key != "" {
+		certFile, keyFile := cfg.Path(cfg.Server.Certificate), cfg.Path(cfg.Server.Key)
+		server := newServer(cfg.Server.TLSPort, router)
+		servers = append(servers, server)
+		go func() {
+			logrus.WithField("addr", server.Addr).Info("serving https")
+			errs <- server.ListenAndServeTLS(certFile, keyFile)
+		}()
+		if cfg.Server.Redirect {
+			handler = redirectHandler(cfg.Server.TLSPort)
+		}
+	}
+	server := newServer(cfg.Server.Port, handler)
+	servers = append(servers, server)
+	go func() {
+		logrus.WithField("addr", server.Addr).Info("serving http")
+		errs <- server.ListenAndServe()
+	}()
+	var err error
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+	}
+	if shutdownErr := shutdown(servers, cfg.Server.ShutdownTimeout); err == nil {
+		err = shutdownErr
+	}
+	return err
+}
+
+// shutdown gracefully stops the servers, waiting at most for the given timeout.
+func shutdown(servers []*http.Server, timeout time.Duration) error {
+	logrus.WithField("timeout", timeout).Info("shutting down")
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var err error
+	for _, server := range servers {
+		if shutdownErr := server.Shutdown(ctx); shutdownErr != nil && err == nil {
+			err = shutdownErr
+		}
+	}
+	return err
+}
+
+// newServer creates a HTTP server listening on the given port.
+func newServer(port int, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
 		ReadHeaderTimeout: time.Minute,
 		ReadTimeout:       time.Minute,
 		WriteTimeout:      time.Minute,
-		Handler:           router.mux,
+		Handler:           handler,
 	}
-	return server.ListenAndServe()
+}
+
+// RedirectHandler redirects plain HTTP requests to HTTPS on the given port.
+func redirectHandler(tlsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if tlsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(tlsPort))
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
 }
 
 // NewRouter configures a new blog router.
 func NewRouter(cfg *config.Config, templater *content.Templater) *Router {
 	rtr := &Router{
-		mux:       mux.NewRouter(),
 		templater: templater,
 		config:    cfg,
 	}
-	rtr.mux.PathPrefix(StaticBaseURL).Handler(
+	rtr.mux = rtr.routes(cfg)
+	return rtr
+}
+
+// routes registers the blog routes for the given configuration.
+// Redirects are matched first so that aliases take precedence over the other routes.
+func (router *Router) routes(cfg *config.Config) *mux.Router {
+	routes := mux.NewRouter()
+	routes.MatcherFunc(router.matchRedirect).Handler(router.redirectHandler())
+	routes.PathPrefix(StaticBaseURL).Handler(
 		http.StripPrefix(StaticBaseURL, http.FileServer(http.Dir(path.Join(cfg.Base, StaticFolder)))))
-	rtr.mux.Handle(IndexBaseURL, rtr.indexHandler())
+	routes.Handle(IndexBaseURL, router.indexHandler())
+	routes.Handle(IndexPageBaseURL+"{page:[0-9]+}", router.indexHandler())
+	routes.Handle(ArchiveURL, router.archiveHandler())
 	if cfg.Meta.Favicon != "" {
-		rtr.mux.Handle(FaviconBaseURL, rtr.faviconHandler())
+		routes.Handle(FaviconBaseURL, router.faviconHandler())
 	}
-	rtr.mux.Handle(PostBaseURL+"{slug}", rtr.postHandler())
-	rtr.mux.Handle(PageBaseURL+"{slug}", rtr.pageHandler())
-	return rtr
+	routes.Handle(FeedAtomURL, router.feedHandler("application/atom+xml; charset=utf-8", (*feeds.Feed).WriteAtom))
+	routes.Handle(SearchURL, router.searchHandler())
+	routes.Handle(SearchJSONURL, router.searchJSONHandler())
+	routes.Handle(HighlightURL, router.highlightHandler())
+	routes.Handle(SitemapURL, router.sitemapHandler())
+	routes.Handle(RobotsURL, router.robotsHandler())
+	routes.Handle(FeedRSSURL, router.feedHandler("application/rss+xml; charset=utf-8", (*feeds.Feed).WriteRss))
+	routes.Handle(permalinkRoute(cfg.Posts.Permalink), router.postHandler())
+	routes.Handle(TagBaseURL+"{name}", router.tagHandler())
+	routes.Handle(PageBaseURL+"{slug}", router.pageHandler())
+	return routes
+}
+
+// redirect returns the target of a redirected path.
+// Configured redirects take precedence over aliases and redirects to the path itself are ignored.
+func redirect(cfg *config.Config, templater *content.Templater, from string) (string, bool) {
+	to, ok := cfg.Redirects[from]
+	if !ok {
+		to, ok = templater.Index().URLByAlias[from]
+	}
+	return to, ok && to != from
+}
+
+// redirects collects the aliases of all entries and the redirects configured in the config.
+func redirects(cfg *config.Config, templater *content.Templater) map[string]string {
+	redirects := make(map[string]string)
+	for from := range templater.Index().URLByAlias {
+		if to, ok := redirect(cfg, templater, from); ok {
+			redirects[from] = to
+		}
+	}
+	for from := range cfg.Redirects {
+		if to, ok := redirect(cfg, templater, from); ok {
+			redirects[from] = to
+		}
+	}
+	return redirects
+}
+
+// matchRedirect matches requests to redirected paths.
+// Aliases are resolved at request time, so aliases of scheduled posts apply once they are published.
+func (router *Router) matchRedirect(r *http.Request, match *mux.RouteMatch) bool {
+	_, templater, cfg := router.current()
+	_, ok := redirect(cfg, templater, r.URL.Path)
+	return ok
+}
+
+// RedirectHandler permanently redirects aliases and configured paths to their target.
+func (router *Router) redirectHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, templater, cfg := router.current()
+		to, ok := redirect(cfg, templater, r.URL.Path)
+		if !ok {
+			router.error(w, errors.New("page not found"), 404)
+			return
+		}
+		http.Redirect(w, r, to, http.StatusMovedPermanently)
+	})
+}
+
+// permalinkRoute converts a permalink pattern like /:year/:month/:slug/ into a route template.
+func permalinkRoute(permalink string) string {
+	return strings.NewReplacer(
+		":year", "{year:[0-9]{4}}",
+		":month", "{month:[0-9]{2}}",
+		":day", "{day:[0-9]{2}}",
+		":slug", "{slug}",
+	).Replace(permalink)
 }
 
 type simpleResolver struct {
@@ -138,8 +618,24 @@ func (r *simpleResolver) Page(slug string) string {
 	return fmt.Sprintf("%s%s", PageBaseURL, slug)
 }
 
-func (r *simpleResolver) Post(slug string) string {
-	return fmt.Sprintf("%s%s", PostBaseURL, slug)
+func (r *simpleResolver) Post(slug string, date time.Time) string {
+	return strings.NewReplacer(
+		":year", date.Format("2006"),
+		":month", date.Format("01"),
+		":day", date.Format("02"),
+		":slug", slug,
+	).Replace(r.cfg.Posts.Permalink)
+}
+
+func (r *simpleResolver) Tag(name string) string {
+	return fmt.Sprintf("%s%s", TagBaseURL, name)
+}
+
+func (r *simpleResolver) Index(number int) string {
+	if number <= 1 {
+		return IndexBaseURL
+	}
+	return fmt.Sprintf("%s%d", IndexPageBaseURL, number)
 }
 
 // NewResolver creates a new simple URL resolver.
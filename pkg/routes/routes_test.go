@@ -0,0 +1,234 @@
+package routes
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lnsp/bloggy/pkg/config"
+	"github.com/lnsp/bloggy/pkg/content"
+)
+
+// testBlog contains the files of a small blog using the default theme.
+var testBlog = map[string]string{
+	"config.yaml": `meta:
+  url: https://blog.example.com
+  title: Test Blog
+posts:
+  pagesize: 1
+`,
+	"posts/first.md": `---
+title: First post
+date: 2020-Jan-05
+tags: [go, web]
+---
+Hello world!
+`,
+	"posts/second.md": `---
+title: Second post
+date: 2020-Feb-10
+tags: [go]
+aliases: [/post/old-second]
+---
+Hello again!
+`,
+	"pages/about.md": `---
+title: About
+---
+About this blog.
+`,
+}
+
+// writeBlog writes the test blog into a temporary folder.
+func writeBlog(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "bloggy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	for name, data := range testBlog {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// openBlog loads the config and templater of a blog folder.
+func openBlog(dir string) (*config.Config, *content.Templater, error) {
+	cfg, err := config.Load(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	index, err := content.NewIndex(cfg, NewResolver(cfg))
+	if err != nil {
+		return nil, nil, err
+	}
+	templater, err := content.NewTemplater(cfg, index)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, templater, nil
+}
+
+func TestRouterConcurrentRequests(t *testing.T) {
+	dir := writeBlog(t)
+	cfg, templater, err := openBlog(dir)
+	if err != nil {
+		t.Fatalf("open blog: %v", err)
+	}
+	router := NewRouter(cfg, templater)
+	urls := append(router.URLs(), SearchURL+"?q=hello", SearchJSONURL+"?q=hello")
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	// Swap the templater and clear the caches while requests are served
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				cfg, templater, err := openBlog(dir)
+				if err != nil {
+					t.Errorf("open blog: %v", err)
+					return
+				}
+				router.Reload(cfg, templater)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_, templater, _ := router.current()
+				templater.ClearCache()
+			}
+		}
+	}()
+
+	var requests sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		requests.Add(1)
+		go func() {
+			defer requests.Done()
+			for i := 0; i < 20; i++ {
+				for _, url := range urls {
+					recorder := httptest.NewRecorder()
+					router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, url, nil))
+					if recorder.Code != http.StatusOK {
+						t.Errorf("GET %s = %d, want %d", url, recorder.Code, http.StatusOK)
+						return
+					}
+				}
+			}
+		}()
+	}
+	requests.Wait()
+	close(stop)
+	wg.Wait()
+}
+
+func TestRouterRedirects(t *testing.T) {
+	dir := writeBlog(t)
+	files := map[string]string{
+		"posts/braces.md":    "---\ntitle: Braces\ndate: 2020-Mar-01\naliases: [\"/old/{id}\", \"legacy/[a-z]+\"]\n---\nBraces\n",
+		"posts/scheduled.md": "---\ntitle: Scheduled\ndate: 2100-Jan-01\naliases: [/post/soon]\n---\nSoon\n",
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg, templater, err := openBlog(dir)
+	if err != nil {
+		t.Fatalf("open blog: %v", err)
+	}
+	cfg.Redirects = map[string]string{"/github": "https://github.com"}
+	router := NewRouter(cfg, templater)
+
+	tests := []struct {
+		path     string
+		status   int
+		location string
+	}{
+		{"/post/old-second", http.StatusMovedPermanently, "/post/second"},
+		{"/old/{id}", http.StatusMovedPermanently, "/post/braces"},
+		{"/old/1", http.StatusNotFound, ""},
+		{"/legacy/[a-z]+", http.StatusMovedPermanently, "/post/braces"},
+		{"/legacy/abc", http.StatusNotFound, ""},
+		{"/github", http.StatusMovedPermanently, "https://github.com"},
+		{"/post/soon", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.URL = &url.URL{Path: test.path}
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != test.status {
+			t.Errorf("GET %s = %d, want %d", test.path, recorder.Code, test.status)
+		}
+		if location := recorder.Header().Get("Location"); location != test.location {
+			t.Errorf("GET %s redirects to %q, want %q", test.path, location, test.location)
+		}
+	}
+
+	// Aliases of scheduled posts apply once the post is published
+	index := templater.Index()
+	if _, ok := index.At(time.Date(2099, time.December, 31, 0, 0, 0, 0, time.UTC)).URLByAlias["/post/soon"]; ok {
+		t.Error("alias of scheduled post applies before publishing")
+	}
+	published, err := content.NewTemplater(cfg, index.At(time.Date(2100, time.January, 2, 0, 0, 0, 0, time.UTC)))
+	if err != nil {
+		t.Fatalf("new templater: %v", err)
+	}
+	recorder := httptest.NewRecorder()
+	NewRouter(cfg, published).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/post/soon", nil))
+	if location := recorder.Header().Get("Location"); recorder.Code != http.StatusMovedPermanently || location != "/post/scheduled" {
+		t.Errorf("GET /post/soon = %d to %q, want %d to /post/scheduled", recorder.Code, location, http.StatusMovedPermanently)
+	}
+}
+
+func TestRouterSaveSpecialTags(t *testing.T) {
+	dir := writeBlog(t)
+	post := "---\ntitle: Languages\ndate: 2020-Mar-01\ntags: [\"C#\", \"c/c++\", \"what?\", \"???\"]\n---\nLanguages\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "posts", "languages.md"), []byte(post), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, templater, err := openBlog(dir)
+	if err != nil {
+		t.Fatalf("open blog: %v", err)
+	}
+	router := NewRouter(cfg, templater)
+	for _, tag := range []string{"/tag/c", "/tag/c-c", "/tag/what", "/tag/C%23"} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tag, nil))
+		if recorder.Code != http.StatusOK {
+			t.Errorf("GET %s = %d, want %d", tag, recorder.Code, http.StatusOK)
+		}
+	}
+	out, err := ioutil.TempDir("", "bloggy-out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(out)
+	if err := router.Save(out); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+// Package watch notifies about changes to the files of a blog folder.
+package watch
+
+import (
+	"os"
+	"path"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/lnsp/bloggy/pkg/content"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultDelay is the default time to wait for further changes before notifying.
+const DefaultDelay = 250 * time.Millisecond
+
+// Folders are the blog subfolders watched in addition to the blog folder itself.
+var Folders = []string{
+	content.PostsFolder,
+	content.PagesFolder,
+	content.TemplateFolder,
+	path.Join(content.TemplateFolder, content.DisplayFolder),
+	path.Join(content.TemplateFolder, content.IncludeFolder),
+}
+
+// Watcher calls a function whenever files in a blog folder change.
+type Watcher struct {
+	watcher  *fsnotify.Watcher
+	dirs     []string
+	delay    time.Duration
+	callback func()
+	done     chan struct{}
+}
+
+// New starts watching the blog folder and its content subfolders.
+// Subfolders created later on are watched as soon as they appear.
+// Changes happening within the delay are collapsed into a single callback.
+func New(folder string, delay time.Duration, callback func()) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	dirs := []string{path.Clean(folder)}
+	for _, sub := range Folders {
+		dirs = append(dirs, path.Join(folder, sub))
+	}
+	w := &Watcher{
+		watcher:  fsw,
+		dirs:     dirs,
+		delay:    delay,
+		callback: callback,
+		done:     make(chan struct{}),
+	}
+	if err := w.add(); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+// add watches all existing folders of the blog.
+func (w *Watcher) add() error {
+	for _, dir := range w.dirs {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		if err := w.watcher.Add(dir); err != nil {
+			return err
+		}
+		logrus.WithField("dir", dir).Debug("watching directory")
+	}
+	return nil
+}
+
+// watches reports if the path is one of the watched folders.
+func (w *Watcher) watches(name string) bool {
+	name = path.Clean(name)
+	for _, dir := range w.dirs {
+		if dir == name {
+			return true
+		}
+	}
+	return false
+}
+
+// run waits for file events and triggers the callback once no more events arrive.
+func (w *Watcher) run() {
+	var timer *time.Timer
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			logrus.WithField("event", event).Debug("file changed")
+			// Nested folders may have been created before the parent was watched, so add them all
+			if event.Op&fsnotify.Create != 0 && w.watches(event.Name) {
+				if err := w.add(); err != nil {
+					logrus.WithError(err).Warn("failed to watch")
+				}
+			}
+			if timer == nil {
+				timer = time.AfterFunc(w.delay, w.callback)
+			} else {
+				timer.Reset(w.delay)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			logrus.WithError(err).Warn("failed to watch")
+		case <-w.done:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+	}
+}
+
+// Close stops watching the blog folder.
+func (w *Watcher) Close() error {
+	close(w.done)
+	return w.watcher.Close()
+}
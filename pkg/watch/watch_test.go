@@ -0,0 +1,40 @@
+package watch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcherCreatedFolders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bloggy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	changes := make(chan struct{}, 16)
+	w, err := New(dir, 10*time.Millisecond, func() { changes <- struct{}{} })
+	if err != nil {
+		t.Fatalf("new watcher: %v", err)
+	}
+	defer w.Close()
+	wait := func(what string) {
+		select {
+		case <-changes:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no change after %s", what)
+		}
+	}
+	// Nested folders created at once must be picked up as well
+	displays := filepath.Join(dir, "templates", "displays")
+	if err := os.MkdirAll(displays, 0755); err != nil {
+		t.Fatal(err)
+	}
+	wait("creating folders")
+	if err := ioutil.WriteFile(filepath.Join(displays, "post.html"), []byte("post"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wait("writing a template")
+}